@@ -0,0 +1,335 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SkyMack/clibase"
+	"github.com/SkyMack/staledesk/internal/client"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	flagNameAPIKey       = "api-key"
+	flagNameData         = "data"
+	flagNameFailuresFile = "failures-file"
+	flagNameFilter       = "filter"
+	flagNameMap          = "map"
+	flagNameOutput       = "output"
+	flagNamePollInterval = "poll-interval"
+	flagNameServerURL    = "server-url"
+)
+
+var (
+	ErrImportFailed  = fmt.Errorf("contact import failed")
+	ErrInvalidFilter = fmt.Errorf("filters must be in the form name=value")
+	ErrInvalidID     = fmt.Errorf("id must be an integer")
+	ErrInvalidMap    = fmt.Errorf("column mappings must be in the form field=column")
+)
+
+// addClientFlags adds the flags shared by every command that talks to a running server
+func addClientFlags(flags *pflag.FlagSet) {
+	clientFlags := &pflag.FlagSet{}
+	clientFlags.String(flagNameServerURL, "http://localhost:5000", "Base URL of the staledesk server or Freshdesk account to send requests to")
+	clientFlags.String(flagNameAPIKey, "", "API key used to authenticate with the server")
+	clientFlags.StringP(flagNameOutput, "o", outputTable, fmt.Sprintf("Output format, one of %s, %s or %s", outputTable, outputJSON, outputCSV))
+
+	clibase.SetFlagsFromEnv(flagPrefix, clientFlags)
+	flags.AddFlagSet(clientFlags)
+}
+
+func newClientFromFlags(flags *pflag.FlagSet) (*client.Client, error) {
+	serverURL, err := flags.GetString(flagNameServerURL)
+	if err != nil {
+		return nil, err
+	}
+	apiKey, err := flags.GetString(flagNameAPIKey)
+	if err != nil {
+		return nil, err
+	}
+	return client.New(serverURL, apiKey), nil
+}
+
+// readDataFlag returns the request body passed with --data, either inline JSON, or @path to read it from a file
+// (@- reads from stdin)
+func readDataFlag(cmd *cobra.Command) ([]byte, error) {
+	data, err := cmd.Flags().GetString(flagNameData)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case data == "@-":
+		return io.ReadAll(cmd.InOrStdin())
+	case strings.HasPrefix(data, "@"):
+		return os.ReadFile(strings.TrimPrefix(data, "@"))
+	default:
+		return []byte(data), nil
+	}
+}
+
+func parseIDArg(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidID, arg)
+	}
+	return id, nil
+}
+
+// runContactsCmd builds the client, runs the request, and prints whatever contacts it returns
+func runContactsCmd(cmd *cobra.Command, request func(*client.Client) ([]models.Contact, error)) error {
+	apiClient, err := newClientFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	format, err := cmd.Flags().GetString(flagNameOutput)
+	if err != nil {
+		return err
+	}
+	contacts, err := request(apiClient)
+	if err != nil {
+		return err
+	}
+	return writeContacts(cmd.OutOrStdout(), format, contacts)
+}
+
+func addContactsListCmd(cmd *cobra.Command) {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "Lists contacts, optionally filtered (i.e. --filter email=foo@example.com)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			filterArgs, err := cmd.Flags().GetStringArray(flagNameFilter)
+			if err != nil {
+				return err
+			}
+			filters := url.Values{}
+			for _, filter := range filterArgs {
+				name, value, found := strings.Cut(filter, "=")
+				if !found {
+					return fmt.Errorf("%w: %s", ErrInvalidFilter, filter)
+				}
+				filters.Add(name, value)
+			}
+			return runContactsCmd(cmd, func(apiClient *client.Client) ([]models.Contact, error) {
+				return apiClient.ListContacts(filters)
+			})
+		},
+	}
+	listCmd.Flags().StringArray(flagNameFilter, nil, "A name=value list filter, may be repeated")
+
+	cmd.AddCommand(listCmd)
+}
+
+func addContactsGetCmd(cmd *cobra.Command) {
+	getCmd := &cobra.Command{
+		Use:   "get ID",
+		Short: "Shows a single contact",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseIDArg(args[0])
+			if err != nil {
+				return err
+			}
+			return runContactsCmd(cmd, func(apiClient *client.Client) ([]models.Contact, error) {
+				contact, err := apiClient.GetContact(id)
+				return []models.Contact{contact}, err
+			})
+		},
+	}
+
+	cmd.AddCommand(getCmd)
+}
+
+func addContactsCreateCmd(cmd *cobra.Command) {
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: "Creates a contact from the JSON given with --data",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := readDataFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return runContactsCmd(cmd, func(apiClient *client.Client) ([]models.Contact, error) {
+				contact, err := apiClient.CreateContact(body)
+				return []models.Contact{contact}, err
+			})
+		},
+	}
+	createCmd.Flags().String(flagNameData, "", "JSON request body, or @path to read it from a file (@- for stdin)")
+	createCmd.MarkFlagRequired(flagNameData)
+
+	cmd.AddCommand(createCmd)
+}
+
+func addContactsUpdateCmd(cmd *cobra.Command) {
+	updateCmd := &cobra.Command{
+		Use:   "update ID",
+		Short: "Updates the fields of a contact given in the JSON passed with --data",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseIDArg(args[0])
+			if err != nil {
+				return err
+			}
+			body, err := readDataFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return runContactsCmd(cmd, func(apiClient *client.Client) ([]models.Contact, error) {
+				contact, err := apiClient.UpdateContact(id, body)
+				return []models.Contact{contact}, err
+			})
+		},
+	}
+	updateCmd.Flags().String(flagNameData, "", "JSON request body, or @path to read it from a file (@- for stdin)")
+	updateCmd.MarkFlagRequired(flagNameData)
+
+	cmd.AddCommand(updateCmd)
+}
+
+func addContactsDeleteCmd(cmd *cobra.Command) {
+	deleteCmd := &cobra.Command{
+		Use:   "delete ID",
+		Short: "Deletes a contact, which Freshdesk keeps as deleted until it is hard deleted",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseIDArg(args[0])
+			if err != nil {
+				return err
+			}
+			apiClient, err := newClientFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+			return apiClient.DeleteContact(id)
+		},
+	}
+
+	cmd.AddCommand(deleteCmd)
+}
+
+func addContactsSearchCmd(cmd *cobra.Command) {
+	searchCmd := &cobra.Command{
+		Use:   "search QUERY",
+		Short: "Searches contacts with a filter query, i.e. \"unique_external_id:'abc'\"",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runContactsCmd(cmd, func(apiClient *client.Client) ([]models.Contact, error) {
+				resp, err := apiClient.SearchContacts(args[0])
+				return resp.Results, err
+			})
+		},
+	}
+
+	cmd.AddCommand(searchCmd)
+}
+
+func addContactsImportCmd(cmd *cobra.Command) {
+	importCmd := &cobra.Command{
+		Use:   "import FILE",
+		Short: "Imports contacts from a CSV file and waits for the import to finish",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			flags := cmd.Flags()
+			mapArgs, err := flags.GetStringArray(flagNameMap)
+			if err != nil {
+				return err
+			}
+			mapping := map[string]string{}
+			for _, arg := range mapArgs {
+				field, column, found := strings.Cut(arg, "=")
+				if !found {
+					return fmt.Errorf("%w: %s", ErrInvalidMap, arg)
+				}
+				mapping[field] = column
+			}
+			pollInterval, err := flags.GetDuration(flagNamePollInterval)
+			if err != nil {
+				return err
+			}
+			failuresFile, err := flags.GetString(flagNameFailuresFile)
+			if err != nil {
+				return err
+			}
+			apiClient, err := newClientFromFlags(flags)
+			if err != nil {
+				return err
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			importID, err := apiClient.ImportContacts(args[0], file, mapping)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "import %d started\n", importID)
+			contactImport, err := apiClient.GetContactImport(importID)
+			for err == nil && contactImport.Status == models.JobStatusInProgress {
+				time.Sleep(pollInterval)
+				contactImport, err = apiClient.GetContactImport(importID)
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "import %d %s: %d of %d records created, %d failed\n",
+				importID, contactImport.Status, contactImport.CreatedRecords, contactImport.TotalRecords, contactImport.FailedRecords)
+
+			if contactImport.FailedRecords > 0 {
+				report, err := apiClient.GetContactImportFailures(importID)
+				if err != nil {
+					return err
+				}
+				if failuresFile != "" {
+					if err := os.WriteFile(failuresFile, report, 0o644); err != nil {
+						return err
+					}
+					fmt.Fprintf(out, "failed rows written to %s\n", failuresFile)
+				} else {
+					fmt.Fprint(out, string(report))
+				}
+			}
+			if contactImport.Status == models.JobStatusFailed {
+				return ErrImportFailed
+			}
+			return nil
+		},
+	}
+	importCmd.Flags().StringArray(flagNameMap, nil, "A field=column mapping, the column is a 0 based index or header name, may be repeated (header names are matched to fields if not set)")
+	importCmd.Flags().Duration(flagNamePollInterval, time.Second, "How often to check whether the import has finished")
+	importCmd.Flags().String(flagNameFailuresFile, "", "Write the failed rows report to this file instead of printing it")
+
+	cmd.AddCommand(importCmd)
+}
+
+func addContactsCmd(cmd *cobra.Command) {
+	contactsCmd := &cobra.Command{
+		Use:   "contacts",
+		Short: "Manage the contacts on a running staledesk server (or a real Freshdesk account)",
+		Annotations: map[string]string{
+			annotationNoConfig: "true",
+		},
+	}
+	addClientFlags(contactsCmd.PersistentFlags())
+
+	addContactsListCmd(contactsCmd)
+	addContactsGetCmd(contactsCmd)
+	addContactsCreateCmd(contactsCmd)
+	addContactsUpdateCmd(contactsCmd)
+	addContactsDeleteCmd(contactsCmd)
+	addContactsSearchCmd(contactsCmd)
+	addContactsImportCmd(contactsCmd)
+	cmd.AddCommand(contactsCmd)
+}
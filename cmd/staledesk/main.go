@@ -2,11 +2,13 @@ package main
 
 import (
 	"os"
+	_ "time/tzdata"
 
 	"github.com/SkyMack/clibase"
 	"github.com/SkyMack/staledesk/config"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 const (
@@ -14,6 +16,12 @@ const (
 	appDescription = "A Freshdesk compatible REST API"
 
 	flagPrefix = "STALE_"
+
+	flagNameConfig = "config"
+
+	// annotationNoConfig marks commands (and their subcommands) that don't read the config file,
+	// such as the ones that only talk to a remote server
+	annotationNoConfig = "staledesk.no-config"
 )
 
 var (
@@ -21,11 +29,18 @@ var (
 		Use:   appName,
 		Short: appDescription,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if skipsConfig(cmd) {
+				return nil
+			}
 			var (
 				conf *config.Data
 				err  error
 			)
-			conf, err = config.GenerateConfigData()
+			configPath, err := cmd.Flags().GetString(flagNameConfig)
+			if err != nil {
+				return err
+			}
+			conf, err = config.GenerateConfigData(configPath)
 			if err != nil {
 				return err
 			}
@@ -36,6 +51,24 @@ var (
 )
 
 func init() {
+	addRootFlags(rootCmd.PersistentFlags())
+}
+
+func skipsConfig(cmd *cobra.Command) bool {
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if _, found := cmd.Annotations[annotationNoConfig]; found {
+			return true
+		}
+	}
+	return false
+}
+
+func addRootFlags(flags *pflag.FlagSet) {
+	rootFlags := &pflag.FlagSet{}
+	rootFlags.String(flagNameConfig, "", "Path to the config file, the format is taken from the extension (json, toml, yaml or yml)")
+
+	clibase.SetFlagsFromEnv(flagPrefix, rootFlags)
+	flags.AddFlagSet(rootFlags)
 }
 
 func main() {
@@ -43,6 +76,9 @@ func main() {
 	rootCmd := clibase.NewUsingCmd(rootCmd)
 	config.AddConfigCmd(rootCmd)
 	addServeCmd(rootCmd)
+	addContactsCmd(rootCmd)
+	addEmailConfigsCmd(rootCmd)
+	addProductsCmd(rootCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		log.WithFields(log.Fields{
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/SkyMack/staledesk/config"
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+const (
+	outputCSV   = "csv"
+	outputJSON  = "json"
+	outputTable = "table"
+)
+
+var (
+	contactColumns     = []string{"ID", "NAME", "EMAIL", "PHONE", "MOBILE", "UNIQUE_EXTERNAL_ID", "UPDATED_AT"}
+	emailConfigColumns = []string{"ID", "NAME", "TO_EMAIL", "REPLY_EMAIL", "PRODUCT_ID", "PRIMARY_ROLE", "ACTIVE"}
+	productColumns     = []string{"ID", "NAME", "PRIMARY_EMAIL", "DESCRIPTION", "UPDATED_AT"}
+)
+
+func contactRow(c models.Contact) []string {
+	return []string{
+		strconv.Itoa(c.ID),
+		c.Name,
+		c.Email,
+		c.Phone,
+		c.Mobile,
+		c.PeopleID,
+		c.UpdatedAt.String(),
+	}
+}
+
+func emailConfigRow(emailConfig models.EmailConfig) []string {
+	productID := ""
+	if emailConfig.ProductID != 0 {
+		productID = strconv.Itoa(emailConfig.ProductID)
+	}
+	return []string{
+		strconv.Itoa(emailConfig.ID),
+		emailConfig.Name,
+		emailConfig.ToEmail,
+		emailConfig.ReplyEmail,
+		productID,
+		strconv.FormatBool(emailConfig.PrimaryRole),
+		strconv.FormatBool(emailConfig.Active),
+	}
+}
+
+func productRow(product models.Product) []string {
+	return []string{
+		strconv.Itoa(product.ID),
+		product.Name,
+		product.PrimaryEmail,
+		product.Description,
+		product.UpdatedAt.String(),
+	}
+}
+
+// writeContacts prints the contacts in the given format. JSON output keeps every field, table and CSV output only
+// the columns most useful for finding a contact.
+func writeContacts(out io.Writer, format string, contacts []models.Contact) error {
+	return writeRecords(out, format, contacts, contactColumns, contactRow)
+}
+
+// writeRecords prints the records in the given format. JSON output keeps every field, table and CSV output the
+// given columns.
+func writeRecords[T any](out io.Writer, format string, records []T, columns []string, row func(T) []string) error {
+	switch format {
+	case outputJSON:
+		return config.WriteJSON(out, records)
+	case outputCSV:
+		writer := csv.NewWriter(out)
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := writer.Write(row(record)); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case outputTable:
+		writer := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, strings.Join(columns, "\t"))
+		for _, record := range records {
+			fmt.Fprintln(writer, strings.Join(row(record), "\t"))
+		}
+		return writer.Flush()
+	default:
+		return fmt.Errorf("%w: %s (must be one of %s, %s, %s)", config.ErrOutputFormatUnsupported, format, outputTable, outputJSON, outputCSV)
+	}
+}
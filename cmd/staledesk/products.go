@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/SkyMack/staledesk/internal/client"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/cobra"
+)
+
+// recordCmds holds the client calls and output columns of a resource managed with list, get, create, update and
+// delete commands
+type recordCmds[T any] struct {
+	Columns []string
+	Create  func(apiClient *client.Client, body []byte) (T, error)
+	Delete  func(apiClient *client.Client, id int) error
+	Get     func(apiClient *client.Client, id int) (T, error)
+	List    func(apiClient *client.Client) ([]T, error)
+	// Noun names a single record in the help text, i.e. "product"
+	Noun   string
+	Row    func(record T) []string
+	Update func(apiClient *client.Client, id int, body []byte) (T, error)
+}
+
+// runRecordsCmd builds the client, runs the request, and prints whatever records it returns
+func (r recordCmds[T]) runRecordsCmd(cmd *cobra.Command, request func(*client.Client) ([]T, error)) error {
+	apiClient, err := newClientFromFlags(cmd.Flags())
+	if err != nil {
+		return err
+	}
+	format, err := cmd.Flags().GetString(flagNameOutput)
+	if err != nil {
+		return err
+	}
+	records, err := request(apiClient)
+	if err != nil {
+		return err
+	}
+	return writeRecords(cmd.OutOrStdout(), format, records, r.Columns, r.Row)
+}
+
+func (r recordCmds[T]) addCmds(cmd *cobra.Command) {
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: fmt.Sprintf("Lists every %s", r.Noun),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return r.runRecordsCmd(cmd, r.List)
+		},
+	}
+
+	getCmd := &cobra.Command{
+		Use:   "get ID",
+		Short: fmt.Sprintf("Shows a single %s", r.Noun),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseIDArg(args[0])
+			if err != nil {
+				return err
+			}
+			return r.runRecordsCmd(cmd, func(apiClient *client.Client) ([]T, error) {
+				record, err := r.Get(apiClient, id)
+				return []T{record}, err
+			})
+		},
+	}
+
+	createCmd := &cobra.Command{
+		Use:   "create",
+		Short: fmt.Sprintf("Creates a %s from the JSON given with --data", r.Noun),
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			body, err := readDataFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return r.runRecordsCmd(cmd, func(apiClient *client.Client) ([]T, error) {
+				record, err := r.Create(apiClient, body)
+				return []T{record}, err
+			})
+		},
+	}
+	createCmd.Flags().String(flagNameData, "", "JSON request body, or @path to read it from a file (@- for stdin)")
+	createCmd.MarkFlagRequired(flagNameData)
+
+	updateCmd := &cobra.Command{
+		Use:   "update ID",
+		Short: fmt.Sprintf("Updates the fields of a %s given in the JSON passed with --data", r.Noun),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseIDArg(args[0])
+			if err != nil {
+				return err
+			}
+			body, err := readDataFlag(cmd)
+			if err != nil {
+				return err
+			}
+			return r.runRecordsCmd(cmd, func(apiClient *client.Client) ([]T, error) {
+				record, err := r.Update(apiClient, id, body)
+				return []T{record}, err
+			})
+		},
+	}
+	updateCmd.Flags().String(flagNameData, "", "JSON request body, or @path to read it from a file (@- for stdin)")
+	updateCmd.MarkFlagRequired(flagNameData)
+
+	deleteCmd := &cobra.Command{
+		Use:   "delete ID",
+		Short: fmt.Sprintf("Deletes a %s", r.Noun),
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := parseIDArg(args[0])
+			if err != nil {
+				return err
+			}
+			apiClient, err := newClientFromFlags(cmd.Flags())
+			if err != nil {
+				return err
+			}
+			return r.Delete(apiClient, id)
+		},
+	}
+
+	cmd.AddCommand(listCmd, getCmd, createCmd, updateCmd, deleteCmd)
+}
+
+func addProductsCmd(cmd *cobra.Command) {
+	productsCmd := &cobra.Command{
+		Use:   "products",
+		Short: "Manage the products on a running staledesk server (or a real Freshdesk account)",
+		Annotations: map[string]string{
+			annotationNoConfig: "true",
+		},
+	}
+	addClientFlags(productsCmd.PersistentFlags())
+
+	recordCmds[models.Product]{
+		Columns: productColumns,
+		Create:  (*client.Client).CreateProduct,
+		Delete:  (*client.Client).DeleteProduct,
+		Get:     (*client.Client).GetProduct,
+		List:    (*client.Client).ListProducts,
+		Noun:    "product",
+		Row:     productRow,
+		Update:  (*client.Client).UpdateProduct,
+	}.addCmds(productsCmd)
+	cmd.AddCommand(productsCmd)
+}
+
+func addEmailConfigsCmd(cmd *cobra.Command) {
+	emailConfigsCmd := &cobra.Command{
+		Use:   "email-configs",
+		Short: "Manage the email configs (support mailboxes) on a running staledesk server (or a real Freshdesk account)",
+		Annotations: map[string]string{
+			annotationNoConfig: "true",
+		},
+	}
+	addClientFlags(emailConfigsCmd.PersistentFlags())
+
+	recordCmds[models.EmailConfig]{
+		Columns: emailConfigColumns,
+		Create:  (*client.Client).CreateEmailConfig,
+		Delete:  (*client.Client).DeleteEmailConfig,
+		Get:     (*client.Client).GetEmailConfig,
+		List:    (*client.Client).ListEmailConfigs,
+		Noun:    "email config",
+		Row:     emailConfigRow,
+		Update:  (*client.Client).UpdateEmailConfig,
+	}.addCmds(emailConfigsCmd)
+	cmd.AddCommand(emailConfigsCmd)
+}
@@ -2,20 +2,37 @@ package main
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
 	"github.com/SkyMack/clibase"
 	"github.com/SkyMack/staledesk/config"
+	"github.com/SkyMack/staledesk/internal/blobs"
+	"github.com/SkyMack/staledesk/internal/clock"
 	"github.com/SkyMack/staledesk/internal/controller"
+	"github.com/SkyMack/staledesk/internal/jobs"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/random"
+	"github.com/SkyMack/staledesk/internal/store"
 	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
 const (
+	adminPathBase        = "/admin"
 	apiPathBase          = "/api/v2"
 	flagNameAuthRequired = "require-auth"
+	flagNameBlobDir      = "blob-dir"
+	flagNameDatabaseFile = "database-file"
+	flagNameFrozenTime   = "frozen-time"
 	flagNameListenHost   = "listen-host"
 	flagNameListenPort   = "listen-port"
+	flagNameSeed         = "seed"
+	flagNameWatchConfig  = "watch-config"
 )
 
 type Server struct {
@@ -28,8 +45,17 @@ type ServeOptions struct {
 	ListenHost   string
 	ListenPort   string
 	AuthRequired bool
+	BlobDir      string
+	DatabaseFile string
+	FrozenTime   string
+	Seed         int64
+	WatchConfig  bool
 }
 
+var (
+	ErrInvalidFrozenTime = fmt.Errorf("frozen time must be an RFC3339 time, i.e. 2023-01-02T15:04:05Z")
+)
+
 func genServerOptionsFromFlags(flags *pflag.FlagSet) (ServeOptions, error) {
 	listenHost, err := flags.GetString(flagNameListenHost)
 	if err != nil {
@@ -43,19 +69,79 @@ func genServerOptionsFromFlags(flags *pflag.FlagSet) (ServeOptions, error) {
 	if err != nil {
 		return ServeOptions{}, err
 	}
+	blobDir, err := flags.GetString(flagNameBlobDir)
+	if err != nil {
+		return ServeOptions{}, err
+	}
+	databaseFile, err := flags.GetString(flagNameDatabaseFile)
+	if err != nil {
+		return ServeOptions{}, err
+	}
+	frozenTime, err := flags.GetString(flagNameFrozenTime)
+	if err != nil {
+		return ServeOptions{}, err
+	}
+	seed, err := flags.GetInt64(flagNameSeed)
+	if err != nil {
+		return ServeOptions{}, err
+	}
+	watchConfig, err := flags.GetBool(flagNameWatchConfig)
+	if err != nil {
+		return ServeOptions{}, err
+	}
 
 	return ServeOptions{
 		ListenHost:   listenHost,
 		ListenPort:   listenPort,
 		AuthRequired: authReq,
+		BlobDir:      blobDir,
+		DatabaseFile: databaseFile,
+		FrozenTime:   frozenTime,
+		Seed:         seed,
+		WatchConfig:  watchConfig,
 	}, nil
 }
 
-func NewServer() *gin.Engine {
+func NewServer(dataStore store.Store, blobStore blobs.Store, jobRunner *jobs.Runner, clk clock.Clock, rng *random.Source) *gin.Engine {
 	router := gin.New()
 	router.SetTrustedProxies(nil)
 
-	contacts := controller.NewContactsController()
+	adminControl := controller.NewAdminController(clk)
+	cannedResponses := controller.NewCannedResponsesController(
+		func() []models.CannedResponseFolder { return config.Current().CannedResponseFolders },
+		func() []models.CannedResponse { return config.Current().CannedResponses },
+		dataStore,
+	)
+	contacts := controller.NewContactsController(dataStore, blobStore, jobRunner, clk, rng)
+	if err := jobRunner.FailInterrupted(contacts.FailInterruptedJob); err != nil {
+		log.WithField("error", err.Error()).Error("unable to fail the jobs interrupted by the last shutdown")
+	}
+	files := controller.NewFilesController(blobStore)
+	products := controller.NewProductsController(dataStore, clk, rng)
+	solutions := controller.NewSolutionsController(dataStore, clk, rng)
+	sla := controller.NewSLAController(
+		func() []models.BusinessHours { return config.Current().BusinessHours },
+		func() []models.SLAPolicy { return config.Current().SLAPolicies },
+		clk,
+	)
+	surveys := controller.NewSurveysController(
+		func() []models.Survey { return config.Current().Surveys },
+		func() []models.SatisfactionRating { return config.Current().SatisfactionRatings },
+		clk,
+	)
+	jobsControl := controller.NewJobsController(jobRunner)
+
+	// Uploaded files (i.e. avatars) are served outside the API path, their URLs are returned in avatar_url
+	router.GET(fmt.Sprintf("%s/:%s", controller.FilesPath, controller.ParamNameFileID), files.GetByID)
+
+	adminBase := router.Group(adminPathBase)
+	{
+		adminBase.GET("/clock", adminControl.GetClock)
+		adminBase.POST("/clock", adminControl.SetClock)
+		// Replies aren't emulated, so this shows what applying a canned response to one would insert
+		adminBase.POST(fmt.Sprintf("/canned_responses/:%s/render", controller.ParamNameCannedResponseID), cannedResponses.Render)
+		adminBase.GET("/sla_due_dates", sla.GetDueDates)
+	}
 
 	apiBase := router.Group(apiPathBase)
 	{
@@ -70,32 +156,266 @@ func NewServer() *gin.Engine {
 			// Requests ending in "contacts/autocomplete"
 			contactsGroup.GET("/autocomplete", contacts.Search)
 
+			// Requests ending in "contacts/imports" or "contacts/imports/ID_NUMBER"
+			contactsGroup.POST("/imports", contacts.Import)
+			contactsGroup.GET(fmt.Sprintf("/imports/:%s", controller.ParamNameContactID), contacts.GetImport)
+			contactsGroup.GET(fmt.Sprintf("/imports/:%s/failures", controller.ParamNameContactID), contacts.GetImportFailures)
+
+			// Requests ending in "contacts/export" or "contacts/export/ID_NUMBER"
+			contactsGroup.POST("/export", contacts.Export)
+			contactsGroup.GET(fmt.Sprintf("/export/:%s", controller.ParamNameContactID), contacts.GetExport)
+			contactsGroup.GET(fmt.Sprintf("/export/:%s/download", controller.ParamNameContactID), contacts.DownloadExport)
+
+			// Requests ending in "contacts/ID_NUMBER/avatar"
+			contactsGroup.DELETE(fmt.Sprintf("/:%s/avatar", controller.ParamNameContactID), contacts.DeleteAvatar)
+
+			// Requests ending in "contacts/ID_NUMBER/hard_delete" or "contacts/ID_NUMBER/restore"
+			contactsGroup.DELETE(fmt.Sprintf("/:%s/hard_delete", controller.ParamNameContactID), contacts.HardDelete)
+			contactsGroup.PUT(fmt.Sprintf("/:%s/restore", controller.ParamNameContactID), contacts.Restore)
+
 			// Requests ending in "contacts/ID_NUMBER"
 			contactsGroup.DELETE(fmt.Sprintf("/:%s", controller.ParamNameContactID), contacts.Delete)
 			contactsGroup.GET(fmt.Sprintf("/:%s", controller.ParamNameContactID), contacts.GetByID)
 			contactsGroup.PUT(fmt.Sprintf("/:%s", controller.ParamNameContactID), contacts.Update)
 		}
 
+		cannedFoldersGroup := apiBase.Group("canned_response_folders")
+		{
+			cannedFoldersGroup.GET("", cannedResponses.ListFolders)
+			cannedFoldersGroup.GET(fmt.Sprintf("/:%s", controller.ParamNameCannedResponseFolderID), cannedResponses.GetFolder)
+			cannedFoldersGroup.GET(fmt.Sprintf("/:%s/responses", controller.ParamNameCannedResponseFolderID), cannedResponses.ListFolderResponses)
+		}
+		apiBase.GET(fmt.Sprintf("/canned_responses/:%s", controller.ParamNameCannedResponseID), cannedResponses.GetResponse)
+
+		businessHoursGroup := apiBase.Group("business_hours")
+		{
+			businessHoursGroup.GET("", sla.ListBusinessHours)
+			businessHoursGroup.GET(fmt.Sprintf("/:%s", controller.ParamNameBusinessHoursID), sla.GetBusinessHours)
+		}
+
+		emailConfigsGroup := apiBase.Group("email_configs")
+		{
+			emailConfigsGroup.GET("", products.ListEmailConfigs)
+			emailConfigsGroup.POST("", products.AddEmailConfig)
+			emailConfigsGroup.DELETE(fmt.Sprintf("/:%s", controller.ParamNameEmailConfigID), products.DeleteEmailConfig)
+			emailConfigsGroup.GET(fmt.Sprintf("/:%s", controller.ParamNameEmailConfigID), products.GetEmailConfig)
+			emailConfigsGroup.PUT(fmt.Sprintf("/:%s", controller.ParamNameEmailConfigID), products.UpdateEmailConfig)
+		}
+
+		jobsGroup := apiBase.Group("jobs")
+		{
+			jobsGroup.GET(fmt.Sprintf("/:%s", controller.ParamNameJobID), jobsControl.GetByID)
+		}
+
+		apiBase.GET("/sla_policies", sla.ListPolicies)
+
+		productsGroup := apiBase.Group("products")
+		{
+			productsGroup.GET("", products.ListProducts)
+			productsGroup.POST("", products.AddProduct)
+			productsGroup.DELETE(fmt.Sprintf("/:%s", controller.ParamNameProductID), products.DeleteProduct)
+			productsGroup.GET(fmt.Sprintf("/:%s", controller.ParamNameProductID), products.GetProduct)
+			productsGroup.PUT(fmt.Sprintf("/:%s", controller.ParamNameProductID), products.UpdateProduct)
+		}
+
+		solutionsGroup := apiBase.Group("solutions")
+		{
+			idPath := fmt.Sprintf("/:%s", controller.ParamNameSolutionID)
+			translationPath := fmt.Sprintf("%s/:%s", idPath, controller.ParamNameLanguage)
+
+			categoriesGroup := solutionsGroup.Group("categories")
+			categoriesGroup.GET("", solutions.ListCategories)
+			categoriesGroup.POST("", solutions.AddCategory)
+			categoriesGroup.GET(idPath, solutions.GetCategory)
+			categoriesGroup.PUT(idPath, solutions.UpdateCategory)
+			categoriesGroup.DELETE(idPath, solutions.DeleteCategory)
+			categoriesGroup.GET(idPath+"/folders", solutions.ListFolders)
+			categoriesGroup.POST(idPath+"/folders", solutions.AddFolder)
+			categoriesGroup.GET(fmt.Sprintf("%s/folders/:%s", idPath, controller.ParamNameLanguage), solutions.ListFolderTranslations)
+			categoriesGroup.GET(translationPath, solutions.GetCategoryTranslation)
+			categoriesGroup.POST(translationPath, solutions.CreateCategoryTranslation)
+			categoriesGroup.PUT(translationPath, solutions.UpdateCategoryTranslation)
+
+			foldersGroup := solutionsGroup.Group("folders")
+			foldersGroup.GET(idPath, solutions.GetFolder)
+			foldersGroup.PUT(idPath, solutions.UpdateFolder)
+			foldersGroup.DELETE(idPath, solutions.DeleteFolder)
+			foldersGroup.GET(idPath+"/articles", solutions.ListArticles)
+			foldersGroup.POST(idPath+"/articles", solutions.AddArticle)
+			foldersGroup.GET(fmt.Sprintf("%s/articles/:%s", idPath, controller.ParamNameLanguage), solutions.ListArticleTranslations)
+			foldersGroup.GET(translationPath, solutions.GetFolderTranslation)
+			foldersGroup.POST(translationPath, solutions.CreateFolderTranslation)
+			foldersGroup.PUT(translationPath, solutions.UpdateFolderTranslation)
+
+			articlesGroup := solutionsGroup.Group("articles")
+			articlesGroup.GET(idPath, solutions.GetArticle)
+			articlesGroup.PUT(idPath, solutions.UpdateArticle)
+			articlesGroup.DELETE(idPath, solutions.DeleteArticle)
+			articlesGroup.GET(translationPath, solutions.GetArticleTranslation)
+			articlesGroup.POST(translationPath, solutions.CreateArticleTranslation)
+			articlesGroup.PUT(translationPath, solutions.UpdateArticleTranslation)
+		}
+
+		surveysGroup := apiBase.Group("surveys")
+		{
+			surveysGroup.GET("", surveys.GetAll)
+			surveysGroup.GET("/satisfaction_ratings", surveys.GetRatings)
+		}
+
 		searchGroup := apiBase.Group("search")
 		{
 			searchGroup.GET("/contacts", contacts.Filter)
+			searchGroup.GET("/solutions", solutions.Search)
 		}
 	}
 
 	return router
 }
 
+// openStore returns a SQLite store when a database file is configured, otherwise an in-memory one.
+// Either way the contacts from the config file are only loaded when the store is empty.
+func openStore(opts ServeOptions) (store.Store, error) {
+	var (
+		dataStore store.Store
+		err       error
+	)
+	if opts.DatabaseFile != "" {
+		dataStore, err = store.NewSQLite(opts.DatabaseFile)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		dataStore = store.NewMemory()
+	}
+
+	seeded, err := seedStore(dataStore, config.Current())
+	if err != nil {
+		dataStore.Close()
+		return nil, err
+	}
+	log.WithFields(log.Fields{
+		"database.file": opts.DatabaseFile,
+		"seeded":        seeded,
+	}).Info("data store ready")
+	return dataStore, nil
+}
+
+// seedStore adds the seed records of each resource the store doesn't hold any records of yet
+func seedStore(dataStore store.Store, conf *config.Data) (bool, error) {
+	contactsSeeded, err := store.Seed(dataStore, conf.Contacts)
+	if err != nil {
+		return false, err
+	}
+	productsSeeded, err := store.SeedRecords(dataStore, controller.RecordKindProduct, conf.Products, productID)
+	if err != nil {
+		return false, err
+	}
+	emailConfigsSeeded, err := store.SeedRecords(dataStore, controller.RecordKindEmailConfig, conf.EmailConfigs, emailConfigID)
+	if err != nil {
+		return false, err
+	}
+	return contactsSeeded || productsSeeded || emailConfigsSeeded, nil
+}
+
+// reseedStore re-applies the seed records of a reloaded config, leaving alone the ones changed through the API
+// since the previous config was applied
+func reseedStore(dataStore store.Store, previous, conf *config.Data) (log.Fields, error) {
+	contacts, err := store.ApplySeed(dataStore, previous.Contacts, conf.Contacts)
+	if err != nil {
+		return nil, err
+	}
+	products, err := store.ApplyRecordSeed(dataStore, controller.RecordKindProduct, previous.Products, conf.Products, productID)
+	if err != nil {
+		return nil, err
+	}
+	emailConfigs, err := store.ApplyRecordSeed(dataStore, controller.RecordKindEmailConfig, previous.EmailConfigs, conf.EmailConfigs, emailConfigID)
+	if err != nil {
+		return nil, err
+	}
+	return log.Fields{
+		"contacts.applied":      contacts,
+		"contacts.count":        len(conf.Contacts),
+		"email_configs.applied": emailConfigs,
+		"products.applied":      products,
+	}, nil
+}
+
+func productID(product models.Product) int {
+	return product.ID
+}
+
+func emailConfigID(emailConfig models.EmailConfig) int {
+	return emailConfig.ID
+}
+
 func Serve(opts ServeOptions) error {
-	server := NewServer()
+	dataStore, err := openStore(opts)
+	if err != nil {
+		return err
+	}
+	defer dataStore.Close()
+
+	if opts.WatchConfig {
+		var seedMu sync.Mutex
+		lastSeed := config.Current()
+		config.OnReload(func(conf *config.Data) {
+			seedMu.Lock()
+			defer seedMu.Unlock()
+			applied, err := reseedStore(dataStore, lastSeed, conf)
+			if err != nil {
+				log.WithField("error", err.Error()).Error("unable to re-apply seed records after config reload")
+				return
+			}
+			lastSeed = conf
+			log.WithFields(applied).Info("seed records re-applied")
+		})
+		config.Watch()
+	}
+
+	clk, err := newClock(opts.FrozenTime)
+	if err != nil {
+		return err
+	}
+	rng := random.New(opts.Seed)
+
+	// Job settings are looked up as each job starts, so config reloads apply to the next job
+	jobRunner := jobs.NewRunner(dataStore, config.Current().JobWorkers(), func(action string) (time.Duration, float64) {
+		conf := config.Current()
+		return conf.JobDuration(action), conf.JobFailureRate(action)
+	}, clk, rng.Split())
+
+	blobStore, err := blobs.NewFileStore(opts.BlobDir, clk, rng.Split())
+	if err != nil {
+		return err
+	}
+
+	server := NewServer(dataStore, blobStore, jobRunner, clk, rng)
 	serverBindHost := fmt.Sprintf("%s:%s", opts.ListenHost, opts.ListenPort)
 	return server.Run(serverBindHost)
 }
 
+// newClock returns a clock frozen at frozenTime, or the real clock if it is empty
+func newClock(frozenTime string) (clock.Clock, error) {
+	if frozenTime == "" {
+		return clock.System{}, nil
+	}
+	now, err := time.Parse(time.RFC3339, frozenTime)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidFrozenTime, frozenTime)
+	}
+	return clock.NewFrozen(now), nil
+}
+
 func addServerFlags(flags *pflag.FlagSet) {
 	serveFlags := &pflag.FlagSet{}
 	serveFlags.Bool(flagNameAuthRequired, true, "Whether or not valid client authentication credentials must be used")
+	serveFlags.String(flagNameBlobDir, filepath.Join(os.TempDir(), "staledesk", "blobs"), "Directory uploaded files such as avatars are stored in")
+	serveFlags.String(flagNameDatabaseFile, "", "Path to a SQLite database file to persist data in (data is kept in memory if not set)")
+	serveFlags.String(flagNameFrozenTime, "", "Stop the clock at this RFC3339 time, it then only moves when changed with POST /admin/clock")
 	serveFlags.String(flagNameListenHost, "localhost", "The hostname/IP interface the server will bind to (usually localhost or 0.0.0.0")
 	serveFlags.String(flagNameListenPort, "5000", "The port the server will listen on")
+	serveFlags.Int64(flagNameSeed, 0, "Seed for generating IDs, a fixed seed gives the same IDs every run (0 picks a random seed)")
+	serveFlags.Bool(flagNameWatchConfig, true, "Reload the config file whenever it changes, re-applying seed contacts, products and email configs that haven't been changed through the API")
 
 	clibase.SetFlagsFromEnv(flagPrefix, serveFlags)
 	flags.AddFlagSet(serveFlags)
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyCannedResponseFolders = "data.canned_response_folders"
+	keyCannedResponses       = "data.canned_responses"
+)
+
+var (
+	ErrCannedResponseConfigInvalid = fmt.Errorf("canned responses or their folders in the config are invalid")
+)
+
+// populateCannedResponses reads the canned response folders and responses from the main config file followed by
+// each fixture file. A response without plain text content gets it from its HTML, as Freshdesk does.
+func (cd *Data) populateCannedResponses() error {
+	for _, source := range append([]*viper.Viper{cd.Raw}, cd.Fixtures...) {
+		var (
+			folders   []models.CannedResponseFolder
+			responses []models.CannedResponse
+		)
+		if err := source.UnmarshalKey(keyCannedResponseFolders, &folders, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrCannedResponseConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		if err := source.UnmarshalKey(keyCannedResponses, &responses, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrCannedResponseConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		cd.CannedResponseFolders = append(cd.CannedResponseFolders, folders...)
+		cd.CannedResponses = append(cd.CannedResponses, responses...)
+	}
+
+	folderIDs := map[int]bool{}
+	for _, folder := range cd.CannedResponseFolders {
+		if folder.ID == 0 || folderIDs[folder.ID] {
+			return fmt.Errorf("%w: canned response folder ids must be set and unique, %d is not", ErrCannedResponseConfigInvalid, folder.ID)
+		}
+		folderIDs[folder.ID] = true
+		if folder.Name == "" {
+			return fmt.Errorf("%w: canned response folder %d has no name", ErrCannedResponseConfigInvalid, folder.ID)
+		}
+	}
+	responseIDs := map[int]bool{}
+	for idx, response := range cd.CannedResponses {
+		if response.ID == 0 || responseIDs[response.ID] {
+			return fmt.Errorf("%w: canned response ids must be set and unique, %d is not", ErrCannedResponseConfigInvalid, response.ID)
+		}
+		responseIDs[response.ID] = true
+		if !folderIDs[response.FolderID] {
+			return fmt.Errorf("%w: canned response %d: folder %d does not exist", ErrCannedResponseConfigInvalid, response.ID, response.FolderID)
+		}
+		if response.Title == "" || response.ContentHTML == "" {
+			return fmt.Errorf("%w: canned response %d needs a title and content_html", ErrCannedResponseConfigInvalid, response.ID)
+		}
+		if response.Content == "" {
+			cd.CannedResponses[idx].Content = models.HTMLToText(response.ContentHTML)
+		}
+		cd.CannedResponses[idx].Attachments = []models.Attachment{}
+	}
+	return nil
+}
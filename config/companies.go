@@ -0,0 +1,40 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyCompanies = "data.companies"
+)
+
+var (
+	ErrCompanyConfigInvalid = fmt.Errorf("companies in the config are invalid")
+)
+
+// populateCompanies reads the companies from the main config file followed by each fixture file. Staledesk doesn't
+// serve companies yet, they are only what seed contacts' company_id values are checked against.
+func (cd *Data) populateCompanies() error {
+	for _, source := range append([]*viper.Viper{cd.Raw}, cd.Fixtures...) {
+		var companies []models.Company
+		if err := source.UnmarshalKey(keyCompanies, &companies, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrCompanyConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		cd.Companies = append(cd.Companies, companies...)
+	}
+
+	companyIDs := map[int]bool{}
+	for _, company := range cd.Companies {
+		if company.ID == 0 || companyIDs[company.ID] {
+			return fmt.Errorf("%w: company ids must be set and unique, %d is not", ErrCompanyConfigInvalid, company.ID)
+		}
+		companyIDs[company.ID] = true
+		if company.Name == "" {
+			return fmt.Errorf("%w: company %d has no name", ErrCompanyConfigInvalid, company.ID)
+		}
+	}
+	return nil
+}
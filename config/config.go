@@ -1,18 +1,38 @@
 package config
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/fsnotify/fsnotify"
+	"github.com/mitchellh/mapstructure"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
 )
 
 const (
 	fileName      = "conf"
 	pathConfigDir = "config"
+
+	flagNameOutput = "output"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+
+	// keyFixtures lists extra seed data files, or directories of them, relative to the main config file
+	keyFixtures = "fixtures"
 )
 
 var (
@@ -20,65 +40,350 @@ var (
 
 	ErrCannotPopulateContactsFromConfig = fmt.Errorf("cannot populate contact record from config file")
 	ErrCannotProcessConfig              = fmt.Errorf("unable to process config file")
+	ErrConfigFileNotFound               = fmt.Errorf("config file not found")
+	ErrConfigFileTypeUnsupported        = fmt.Errorf("config file type is not supported")
+	ErrOutputFormatUnsupported          = fmt.Errorf("output format is not supported")
+
+	// supportedExts are the config file formats, inferred from the file extension
+	supportedExts = []string{"json", "toml", "yaml", "yml"}
 
 	pathConfigFile1 = fmt.Sprintf("..%c%s%c", os.PathSeparator, pathConfigDir, os.PathSeparator)
 	pathConfigFile2 = fmt.Sprintf("%s%c", pathConfigDir, os.PathSeparator)
+
+	configMu       sync.RWMutex
+	reloadHandlers []func(*Data)
 )
 
 type Data struct {
-	Contacts map[int]models.Contact
-	Raw      *viper.Viper
+	BusinessHours         []models.BusinessHours
+	CannedResponseFolders []models.CannedResponseFolder
+	CannedResponses       []models.CannedResponse
+	Companies             []models.Company
+	Contacts              map[int]models.Contact
+	EmailConfigs          []models.EmailConfig
+	Fixtures              []*viper.Viper
+	Path                  string
+	Products              []models.Product
+	Raw                   *viper.Viper
+	// SLAPolicies are sorted by position, the order Freshdesk checks them in
+	SLAPolicies []models.SLAPolicy
+	// SatisfactionRatings are sorted by created_at, oldest first
+	SatisfactionRatings []models.SatisfactionRating
+	// SeedContacts holds every contact record exactly as listed in the config and fixture files, including any
+	// that were dropped from Contacts for reusing an ID
+	SeedContacts []SeedContact
+	Surveys      []models.Survey
+}
+
+// SeedContact is a contact record along with where it was defined
+type SeedContact struct {
+	Contact models.Contact
+	File    string
+	Index   int
 }
 
 func SetConfig(conf *Data) {
+	configMu.Lock()
+	defer configMu.Unlock()
 	Config = conf
 }
 
-func GenerateConfigData() (*Data, error) {
+// Current returns the active config, safe to call while a reload is in progress
+func Current() *Data {
+	configMu.RLock()
+	defer configMu.RUnlock()
+	return Config
+}
+
+// OnReload registers a handler that is called with the new config each time the config file is successfully reloaded
+func OnReload(handler func(*Data)) {
+	configMu.Lock()
+	defer configMu.Unlock()
+	reloadHandlers = append(reloadHandlers, handler)
+}
+
+// Watch reloads the config file whenever it or one of its fixture files changes on disk, including a fixture file
+// added to a fixture directory or to the fixtures list. A file that fails to load is logged and the previous config
+// is kept.
+func Watch() {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.WithField("error", err.Error()).Error("unable to watch the config files")
+		return
+	}
+	files, dirs := watchConfigFiles(watcher, Current())
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				name := filepath.Clean(event.Name)
+				changed := files[name] && (event.Has(fsnotify.Write) || event.Has(fsnotify.Create))
+				// Files added to or removed from a fixture directory change the fixture set
+				if dirs[filepath.Dir(name)] && isSupportedExt(name) && event.Op != fsnotify.Chmod {
+					changed = true
+				}
+				if !changed {
+					continue
+				}
+				reload(event)
+				// The reloaded config may list fixture files that aren't watched yet
+				files, dirs = watchConfigFiles(watcher, Current())
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.WithField("error", err.Error()).Error("config file watch failed")
+			}
+		}
+	}()
+}
+
+// watchConfigFiles adds the directory of the config file, of each fixture file, and each fixture directory to the
+// watcher. Directories are watched rather than files so editors that save by replacing the file are caught. It
+// returns the files and the fixture directories a change to should reload the config.
+func watchConfigFiles(watcher *fsnotify.Watcher, conf *Data) (map[string]bool, map[string]bool) {
+	files := map[string]bool{}
+	dirs := map[string]bool{}
+	watch := func(dir string) {
+		if err := watcher.Add(dir); err != nil {
+			log.WithFields(log.Fields{
+				"file.name": dir,
+				"error":     err.Error(),
+			}).Warn("unable to watch config directory")
+		}
+	}
+	for _, source := range append([]*viper.Viper{conf.Raw}, conf.Fixtures...) {
+		file := filepath.Clean(source.ConfigFileUsed())
+		files[file] = true
+		watch(filepath.Dir(file))
+	}
+	for _, fixture := range conf.fixturePaths() {
+		if info, err := os.Stat(fixture); err == nil && info.IsDir() {
+			dirs[filepath.Clean(fixture)] = true
+			watch(fixture)
+		}
+	}
+	return files, dirs
+}
+
+func reload(event fsnotify.Event) {
+	logLn := log.WithFields(log.Fields{
+		"file.name": event.Name,
+		"event":     event.Op.String(),
+	})
+	logLn.Info("config file changed, reloading")
+
+	conf, err := GenerateConfigData(Current().Path)
+	if err != nil {
+		logLn.WithField("error", err.Error()).Error("config reload failed, keeping previous config")
+		return
+	}
+	SetConfig(conf)
+
+	configMu.RLock()
+	handlers := reloadHandlers
+	configMu.RUnlock()
+	for _, handler := range handlers {
+		handler(conf)
+	}
+	logLn.Info("config reloaded")
+}
+
+// GenerateConfigData reads the config file at configPath, or searches the default locations if it is empty,
+// along with any fixture files it references
+func GenerateConfigData(configPath string) (*Data, error) {
 	var err error
 	confData := &Data{
 		Contacts: map[int]models.Contact{},
+		Path:     configPath,
 	}
 	if err = confData.processConfigFile(); err != nil {
 		return &Data{}, err
 	}
+	if err = confData.processFixtures(); err != nil {
+		return &Data{}, err
+	}
+	if err = confData.populateCompanies(); err != nil {
+		return &Data{}, err
+	}
 	if err = confData.populateContacts(); err != nil {
 		return &Data{}, err
 	}
+	if err = confData.populateSLA(); err != nil {
+		return &Data{}, err
+	}
+	if err = confData.populateSurveys(); err != nil {
+		return &Data{}, err
+	}
+	if err = confData.populateCannedResponses(); err != nil {
+		return &Data{}, err
+	}
+	if err = confData.populateProducts(); err != nil {
+		return &Data{}, err
+	}
 	return confData, nil
 }
 
 func (cd *Data) processConfigFile() error {
 	cd.Raw = viper.New()
-	cd.Raw.SetConfigType("json")
-	cd.Raw.SetConfigName(fileName)
-	cd.Raw.AddConfigPath(pathConfigFile1)
-	cd.Raw.AddConfigPath(pathConfigFile2)
+
+	if cd.Path != "" {
+		if err := setConfigFile(cd.Raw, cd.Path); err != nil {
+			return err
+		}
+	} else {
+		cd.Raw.SetConfigName(fileName)
+		cd.Raw.AddConfigPath(pathConfigFile1)
+		cd.Raw.AddConfigPath(pathConfigFile2)
+	}
 
 	err := cd.Raw.ReadInConfig()
+	if errors.As(err, &viper.ConfigFileNotFoundError{}) {
+		searched := searchedPaths()
+		log.WithFields(log.Fields{
+			"file.name":       fileName,
+			"file.searched":   searched,
+			"file.extensions": supportedExts,
+		}).Error(ErrConfigFileNotFound.Error())
+		return fmt.Errorf("%w: searched %s", ErrConfigFileNotFound, strings.Join(searched, ", "))
+	}
 	if err != nil {
 		log.WithFields(log.Fields{
-			"file.name":  fileName,
-			"file.path1": pathConfigFile1,
-			"file.path2": pathConfigFile2,
-			"error":      err.Error(),
-		}).Fatal("unable to read viperConfig file")
-		return ErrCannotProcessConfig
+			"file.name": cd.Raw.ConfigFileUsed(),
+			"error":     err.Error(),
+		}).Error("unable to read viperConfig file")
+		return fmt.Errorf("%w: %s: %s", ErrCannotProcessConfig, cd.Raw.ConfigFileUsed(), err.Error())
 	}
+	cd.Path = cd.Raw.ConfigFileUsed()
 	return nil
 }
 
-func (cd *Data) populateContacts() error {
-	// Populate existing contacts on start-up
-	var defaultContacts []models.Contact
-	if err := cd.Raw.UnmarshalKey("data.contacts", &defaultContacts); err != nil {
+// processFixtures reads every fixture file listed in the main config file.
+// Directories are expanded to the supported files they contain, in name order.
+func (cd *Data) processFixtures() error {
+	for _, fixture := range cd.fixturePaths() {
+		files, err := expandFixturePath(fixture)
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			fixtureConf := viper.New()
+			if err := setConfigFile(fixtureConf, file); err != nil {
+				return err
+			}
+			if err := fixtureConf.ReadInConfig(); err != nil {
+				log.WithFields(log.Fields{
+					"file.name": file,
+					"error":     err.Error(),
+				}).Error("unable to read fixture file")
+				return fmt.Errorf("%w: %s: %s", ErrCannotProcessConfig, file, err.Error())
+			}
+			cd.Fixtures = append(cd.Fixtures, fixtureConf)
+		}
+	}
+	return nil
+}
+
+// fixturePaths returns the fixture files and directories the config file lists, relative ones resolved against the
+// config file's directory
+func (cd *Data) fixturePaths() []string {
+	baseDir := filepath.Dir(cd.Path)
+	var paths []string
+	for _, fixture := range cd.Raw.GetStringSlice(keyFixtures) {
+		if !filepath.IsAbs(fixture) {
+			fixture = filepath.Join(baseDir, fixture)
+		}
+		paths = append(paths, fixture)
+	}
+	return paths
+}
+
+func expandFixturePath(path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: fixture %s", ErrConfigFileNotFound, path)
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: fixture directory %s: %s", ErrCannotProcessConfig, path, err.Error())
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !isSupportedExt(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(path, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// setConfigFile points v at an explicit file, with the format taken from the file extension
+func setConfigFile(v *viper.Viper, path string) error {
+	if _, err := os.Stat(path); err != nil {
 		log.WithFields(log.Fields{
-			"error": err.Error(),
-		}).Fatal("cannot read contacts from config file")
-		return ErrCannotPopulateContactsFromConfig
+			"file.name": path,
+			"error":     err.Error(),
+		}).Error(ErrConfigFileNotFound.Error())
+		return fmt.Errorf("%w: searched %s", ErrConfigFileNotFound, path)
+	}
+	if !isSupportedExt(path) {
+		return fmt.Errorf("%w: %s (must be one of %s)", ErrConfigFileTypeUnsupported, path, strings.Join(supportedExts, ", "))
 	}
+	v.SetConfigFile(path)
+	return nil
+}
 
-	for _, contact := range defaultContacts {
+func isSupportedExt(path string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, supported := range supportedExts {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// searchedPaths lists every file name checked when no explicit config path is set
+func searchedPaths() []string {
+	var searched []string
+	for _, dir := range []string{pathConfigFile1, pathConfigFile2} {
+		for _, ext := range supportedExts {
+			searched = append(searched, fmt.Sprintf("%s%s.%s", dir, fileName, ext))
+		}
+	}
+	return searched
+}
+
+func (cd *Data) populateContacts() error {
+	// Populate existing contacts on start-up, from the main config file followed by each fixture file
+	for _, source := range append([]*viper.Viper{cd.Raw}, cd.Fixtures...) {
+		var sourceContacts []models.Contact
+		if err := source.UnmarshalKey("data.contacts", &sourceContacts, viper.DecodeHook(seedDecodeHook)); err != nil {
+			log.WithFields(log.Fields{
+				"file.name": source.ConfigFileUsed(),
+				"error":     err.Error(),
+			}).Error("cannot read contacts from config file")
+			return fmt.Errorf("%w %s: %s", ErrCannotPopulateContactsFromConfig, source.ConfigFileUsed(), err.Error())
+		}
+		for idx, contact := range sourceContacts {
+			cd.SeedContacts = append(cd.SeedContacts, SeedContact{
+				Contact: contact,
+				File:    source.ConfigFileUsed(),
+				Index:   idx,
+			})
+		}
+	}
+
+	for _, seed := range cd.SeedContacts {
+		contact := seed.Contact
 		log.WithFields(log.Fields{
 			"contact.active":     contact.Active,
 			"contact.address":    contact.Address,
@@ -98,15 +403,122 @@ func (cd *Data) populateContacts() error {
 	return nil
 }
 
+// seedDecodeHook adds timestamp parsing to viper's default decode hooks, so a malformed created_at or updated_at
+// in the seed data stops the config from loading. YAML hands over unquoted timestamps already parsed.
+var seedDecodeHook = mapstructure.ComposeDecodeHookFunc(
+	func(from, to reflect.Type, data interface{}) (interface{}, error) {
+		if to != reflect.TypeOf(models.Time{}) {
+			return data, nil
+		}
+		switch value := data.(type) {
+		case string:
+			var parsed models.Time
+			err := parsed.UnmarshalText([]byte(value))
+			return parsed, err
+		case time.Time:
+			return models.NewTime(value), nil
+		}
+		return data, nil
+	},
+	mapstructure.StringToTimeDurationHookFunc(),
+	mapstructure.StringToSliceHookFunc(","),
+)
+
+// Resolved returns the config settings as read in by viper, with the contacts from every fixture file merged into
+// data.contacts
+func (cd *Data) Resolved() (map[string]interface{}, error) {
+	settings := cd.Raw.AllSettings()
+
+	// Round trip through JSON so the contacts use their API field names rather than the Go ones
+	contacts := make([]models.Contact, 0, len(cd.SeedContacts))
+	for _, seed := range cd.SeedContacts {
+		contacts = append(contacts, seed.Contact)
+	}
+	contactsJSON, err := json.Marshal(contacts)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the numbers exact, large IDs would otherwise be printed as floats in YAML
+	decoder := json.NewDecoder(bytes.NewReader(contactsJSON))
+	decoder.UseNumber()
+	var rawContacts []interface{}
+	if err := decoder.Decode(&rawContacts); err != nil {
+		return nil, err
+	}
+	for idx := range rawContacts {
+		rawContacts[idx] = exactNumbers(rawContacts[idx])
+	}
+
+	data, ok := settings["data"].(map[string]interface{})
+	if !ok {
+		data = map[string]interface{}{}
+		settings["data"] = data
+	}
+	data["contacts"] = rawContacts
+	return settings, nil
+}
+
+// exactNumbers replaces every json.Number in a decoded JSON value with an int64 or float64
+func exactNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = exactNumbers(v[key])
+		}
+	case []interface{}:
+		for idx := range v {
+			v[idx] = exactNumbers(v[idx])
+		}
+	}
+	return value
+}
+
+// WriteJSON prints value as indented JSON, the json output of every command
+func WriteJSON(out io.Writer, value interface{}) error {
+	encoded, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(encoded))
+	return err
+}
+
 func addConfigShowRawData(cmd *cobra.Command) {
 	showRaw := &cobra.Command{
 		Use:   "show-raw",
 		Short: "Prints out the raw config data value as read in by viper",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			//fmt.Println(Config.Raw.Get("data"))
-			return nil
+			format, err := cmd.Flags().GetString(flagNameOutput)
+			if err != nil {
+				return err
+			}
+			settings, err := Current().Resolved()
+			if err != nil {
+				return err
+			}
+
+			switch format {
+			case outputJSON:
+				return WriteJSON(cmd.OutOrStdout(), settings)
+			case outputYAML:
+				out, err := yaml.Marshal(settings)
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprintln(cmd.OutOrStdout(), strings.TrimSpace(string(out)))
+				return err
+			default:
+				return fmt.Errorf("%w: %s (must be %s or %s)", ErrOutputFormatUnsupported, format, outputJSON, outputYAML)
+			}
 		},
 	}
+	showRaw.Flags().StringP(flagNameOutput, "o", outputJSON, fmt.Sprintf("Output format, either %s or %s", outputJSON, outputYAML))
 
 	cmd.AddCommand(showRaw)
 }
@@ -118,5 +530,6 @@ func AddConfigCmd(cmd *cobra.Command) {
 	}
 
 	addConfigShowRawData(configCmd)
+	addConfigValidate(configCmd)
 	cmd.AddCommand(configCmd)
 }
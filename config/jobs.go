@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// keyJobs holds the per job kind settings, i.e. jobs.contact_export.duration
+	keyJobs = "jobs"
+	// keyJobWorkers is the number of jobs that can run at the same time
+	keyJobWorkers = "jobs.workers"
+
+	defaultJobDuration = 3 * time.Second
+	defaultJobWorkers  = 4
+)
+
+// JobDuration returns how long a background job of the given kind should appear to run for before it finishes.
+// It is read from jobs.<kind>.duration as a Go duration string (i.e. "10s"), defaulting to 3s.
+func (cd *Data) JobDuration(kind string) time.Duration {
+	key := fmt.Sprintf("%s.%s.duration", keyJobs, kind)
+	if cd.Raw == nil || !cd.Raw.IsSet(key) {
+		return defaultJobDuration
+	}
+	duration, err := time.ParseDuration(cd.Raw.GetString(key))
+	if err != nil || duration < 0 {
+		log.WithFields(log.Fields{
+			"config.key":   key,
+			"config.value": cd.Raw.GetString(key),
+		}).Warn("invalid job duration in config, using the default")
+		return defaultJobDuration
+	}
+	return duration
+}
+
+// JobFailureRate returns the chance, from 0 to 1, that a background job of the given kind fails without doing
+// any work. It is read from jobs.<kind>.failure_rate, defaulting to 0.
+func (cd *Data) JobFailureRate(kind string) float64 {
+	key := fmt.Sprintf("%s.%s.failure_rate", keyJobs, kind)
+	if cd.Raw == nil || !cd.Raw.IsSet(key) {
+		return 0
+	}
+	rate := cd.Raw.GetFloat64(key)
+	if rate < 0 || rate > 1 {
+		log.WithFields(log.Fields{
+			"config.key":   key,
+			"config.value": cd.Raw.GetString(key),
+		}).Warn("job failure rate must be between 0 and 1, ignoring it")
+		return 0
+	}
+	return rate
+}
+
+// JobWorkers returns the size of the background job worker pool, read from jobs.workers
+func (cd *Data) JobWorkers() int {
+	if cd.Raw == nil || cd.Raw.GetInt(keyJobWorkers) < 1 {
+		return defaultJobWorkers
+	}
+	return cd.Raw.GetInt(keyJobWorkers)
+}
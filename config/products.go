@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyEmailConfigs = "data.email_configs"
+	keyProducts     = "data.products"
+)
+
+var (
+	ErrProductConfigInvalid = fmt.Errorf("products or email configs in the config are invalid")
+)
+
+// populateProducts reads the products and email configs from the main config file followed by each fixture file.
+// Every mailbox must belong to a known product, if any, and a product's primary email must be the reply address of
+// one of its own mailboxes.
+func (cd *Data) populateProducts() error {
+	for _, source := range append([]*viper.Viper{cd.Raw}, cd.Fixtures...) {
+		var (
+			products     []models.Product
+			emailConfigs []models.EmailConfig
+		)
+		if err := source.UnmarshalKey(keyProducts, &products, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrProductConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		if err := source.UnmarshalKey(keyEmailConfigs, &emailConfigs, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrProductConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		cd.Products = append(cd.Products, products...)
+		cd.EmailConfigs = append(cd.EmailConfigs, emailConfigs...)
+	}
+
+	productIDs := map[int]bool{}
+	for _, product := range cd.Products {
+		if product.ID == 0 || productIDs[product.ID] {
+			return fmt.Errorf("%w: product ids must be set and unique, %d is not", ErrProductConfigInvalid, product.ID)
+		}
+		productIDs[product.ID] = true
+		if product.Name == "" {
+			return fmt.Errorf("%w: product %d has no name", ErrProductConfigInvalid, product.ID)
+		}
+	}
+
+	emailConfigIDs := map[int]bool{}
+	toEmails := map[string]int{}
+	primaries := 0
+	for _, emailConfig := range cd.EmailConfigs {
+		if emailConfig.ID == 0 || emailConfigIDs[emailConfig.ID] {
+			return fmt.Errorf("%w: email config ids must be set and unique, %d is not", ErrProductConfigInvalid, emailConfig.ID)
+		}
+		emailConfigIDs[emailConfig.ID] = true
+		if err := emailConfig.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", ErrProductConfigInvalid, err.Error())
+		}
+		if emailConfig.ProductID != 0 && !productIDs[emailConfig.ProductID] {
+			return fmt.Errorf("%w: email config %d: product %d does not exist", ErrProductConfigInvalid, emailConfig.ID, emailConfig.ProductID)
+		}
+		// Incoming mail is routed by its to address, so two mailboxes can't share one
+		toEmail := strings.ToLower(emailConfig.ToEmail)
+		if otherID, ok := toEmails[toEmail]; ok {
+			return fmt.Errorf("%w: email configs %d and %d both use the to_email %s", ErrProductConfigInvalid, otherID, emailConfig.ID, emailConfig.ToEmail)
+		}
+		toEmails[toEmail] = emailConfig.ID
+		if emailConfig.PrimaryRole {
+			primaries++
+		}
+	}
+	if primaries > 1 {
+		return fmt.Errorf("%w: only one email config can have the primary role", ErrProductConfigInvalid)
+	}
+
+	for _, product := range cd.Products {
+		if !product.HasMailbox(cd.EmailConfigs) {
+			return fmt.Errorf("%w: product %d: primary_email %s is not the reply_email of one of its email configs", ErrProductConfigInvalid, product.ID, product.PrimaryEmail)
+		}
+	}
+	return nil
+}
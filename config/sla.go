@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/viper"
+)
+
+const (
+	keyBusinessHours = "data.business_hours"
+	keySLAPolicies   = "data.sla_policies"
+)
+
+var (
+	ErrSLAConfigInvalid = fmt.Errorf("SLA policies or business hours in the config are invalid")
+)
+
+// populateSLA reads the business hours calendars and SLA policies from the main config file followed by each
+// fixture file. They are validated here, since unlike contacts they can't be fixed through the API.
+func (cd *Data) populateSLA() error {
+	for _, source := range append([]*viper.Viper{cd.Raw}, cd.Fixtures...) {
+		var (
+			businessHours []models.BusinessHours
+			slaPolicies   []models.SLAPolicy
+		)
+		if err := source.UnmarshalKey(keyBusinessHours, &businessHours, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrSLAConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		if err := source.UnmarshalKey(keySLAPolicies, &slaPolicies, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrSLAConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		cd.BusinessHours = append(cd.BusinessHours, businessHours...)
+		cd.SLAPolicies = append(cd.SLAPolicies, slaPolicies...)
+	}
+
+	if err := cd.validateBusinessHours(); err != nil {
+		return err
+	}
+	if err := cd.validateSLAPolicies(); err != nil {
+		return err
+	}
+	sort.SliceStable(cd.SLAPolicies, func(i, j int) bool {
+		return cd.SLAPolicies[i].Position < cd.SLAPolicies[j].Position
+	})
+	return nil
+}
+
+func (cd *Data) validateBusinessHours() error {
+	ids := map[int]bool{}
+	defaults := 0
+	for _, calendar := range cd.BusinessHours {
+		if calendar.ID == 0 || ids[calendar.ID] {
+			return fmt.Errorf("%w: business hours ids must be set and unique, %d is not", ErrSLAConfigInvalid, calendar.ID)
+		}
+		ids[calendar.ID] = true
+		if calendar.IsDefault {
+			defaults++
+		}
+		if err := calendar.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", ErrSLAConfigInvalid, err.Error())
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("%w: only one business hours calendar can be the default", ErrSLAConfigInvalid)
+	}
+	return nil
+}
+
+func (cd *Data) validateSLAPolicies() error {
+	ids := map[int]bool{}
+	defaults := 0
+	for _, policy := range cd.SLAPolicies {
+		if policy.ID == 0 || ids[policy.ID] {
+			return fmt.Errorf("%w: SLA policy ids must be set and unique, %d is not", ErrSLAConfigInvalid, policy.ID)
+		}
+		ids[policy.ID] = true
+		if policy.IsDefault {
+			defaults++
+		}
+		for key, target := range policy.SLATarget {
+			valid := false
+			for priority := 1; priority <= 4; priority++ {
+				valid = valid || key == models.SLATargetKey(priority)
+			}
+			if !valid {
+				return fmt.Errorf("%w: SLA policy %d: %q is not one of priority_1 to priority_4", ErrSLAConfigInvalid, policy.ID, key)
+			}
+			if target.RespondWithin <= 0 || target.ResolveWithin < target.RespondWithin {
+				return fmt.Errorf("%w: SLA policy %d: %s needs a positive respond_within no longer than resolve_within", ErrSLAConfigInvalid, policy.ID, key)
+			}
+		}
+	}
+	if defaults > 1 {
+		return fmt.Errorf("%w: only one SLA policy can be the default", ErrSLAConfigInvalid)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/viper"
+)
+
+const (
+	keySatisfactionRatings = "data.satisfaction_ratings"
+	keySurveys             = "data.surveys"
+)
+
+var (
+	ErrSurveyConfigInvalid = fmt.Errorf("surveys or satisfaction ratings in the config are invalid")
+)
+
+// populateSurveys reads the surveys and satisfaction ratings from the main config file followed by each fixture
+// file. Ratings must belong to a survey and a seeded contact, and are sorted oldest first.
+func (cd *Data) populateSurveys() error {
+	for _, source := range append([]*viper.Viper{cd.Raw}, cd.Fixtures...) {
+		var (
+			surveys []models.Survey
+			ratings []models.SatisfactionRating
+		)
+		if err := source.UnmarshalKey(keySurveys, &surveys, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrSurveyConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		if err := source.UnmarshalKey(keySatisfactionRatings, &ratings, viper.DecodeHook(seedDecodeHook)); err != nil {
+			return fmt.Errorf("%w %s: %s", ErrSurveyConfigInvalid, source.ConfigFileUsed(), err.Error())
+		}
+		cd.Surveys = append(cd.Surveys, surveys...)
+		cd.SatisfactionRatings = append(cd.SatisfactionRatings, ratings...)
+	}
+
+	if err := cd.validateSurveys(); err != nil {
+		return err
+	}
+	if err := cd.validateSatisfactionRatings(); err != nil {
+		return err
+	}
+	sort.SliceStable(cd.SatisfactionRatings, func(i, j int) bool {
+		return cd.SatisfactionRatings[i].CreatedAt.Before(cd.SatisfactionRatings[j].CreatedAt.Time)
+	})
+	return nil
+}
+
+func (cd *Data) validateSurveys() error {
+	ids := map[int]bool{}
+	active := 0
+	for _, survey := range cd.Surveys {
+		if survey.ID == 0 || ids[survey.ID] {
+			return fmt.Errorf("%w: survey ids must be set and unique, %d is not", ErrSurveyConfigInvalid, survey.ID)
+		}
+		ids[survey.ID] = true
+		if survey.Active {
+			active++
+		}
+		if err := survey.Validate(); err != nil {
+			return fmt.Errorf("%w: %s", ErrSurveyConfigInvalid, err.Error())
+		}
+	}
+	if active > 1 {
+		return fmt.Errorf("%w: only one survey can be active", ErrSurveyConfigInvalid)
+	}
+	return nil
+}
+
+// validateSatisfactionRatings checks each rating answers an existing survey and was given by a seeded contact.
+// ticket_id only has to be set, as there are no tickets to check it against.
+func (cd *Data) validateSatisfactionRatings() error {
+	surveys := map[int]models.Survey{}
+	for _, survey := range cd.Surveys {
+		surveys[survey.ID] = survey
+	}
+	ids := map[int]bool{}
+	for _, rating := range cd.SatisfactionRatings {
+		if rating.ID == 0 || ids[rating.ID] {
+			return fmt.Errorf("%w: satisfaction rating ids must be set and unique, %d is not", ErrSurveyConfigInvalid, rating.ID)
+		}
+		ids[rating.ID] = true
+		survey, ok := surveys[rating.SurveyID]
+		if !ok {
+			return fmt.Errorf("%w: satisfaction rating %d: survey %d does not exist", ErrSurveyConfigInvalid, rating.ID, rating.SurveyID)
+		}
+		if _, ok := cd.Contacts[rating.UserID]; !ok {
+			return fmt.Errorf("%w: satisfaction rating %d: user_id %d is not a seeded contact", ErrSurveyConfigInvalid, rating.ID, rating.UserID)
+		}
+		if rating.TicketID < 1 {
+			return fmt.Errorf("%w: satisfaction rating %d: ticket_id must be set", ErrSurveyConfigInvalid, rating.ID)
+		}
+		if err := survey.CheckRatings(rating.Ratings); err != nil {
+			return fmt.Errorf("%w: satisfaction rating %d: %s", ErrSurveyConfigInvalid, rating.ID, err.Error())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrConfigInvalid = fmt.Errorf("config seed data is invalid")
+)
+
+// SeedReport lists the problems found with a single seed record
+type SeedReport struct {
+	Seed   SeedContact
+	Errors models.FieldErrors
+}
+
+func (sc SeedContact) String() string {
+	return fmt.Sprintf("%s data.contacts[%d] (id %d)", sc.File, sc.Index, sc.Contact.ID)
+}
+
+// ValidateSeedData checks every seed contact the same way the API would check a new contact, along with the rules
+// that only apply across records, such as unique IDs and company references. Only records with at least one problem
+// are reported.
+func (cd *Data) ValidateSeedData() []SeedReport {
+	contacts := make([]models.Contact, 0, len(cd.SeedContacts))
+	for _, seed := range cd.SeedContacts {
+		contacts = append(contacts, seed.Contact)
+	}
+	// A contact's own values don't count against it, as long as its ID is unique, which listInvalidSeedIDs checks
+	index := models.NewContactIndex(contacts)
+	companyIDs := map[int]bool{}
+	for _, company := range cd.Companies {
+		companyIDs[company.ID] = true
+	}
+
+	var reports []SeedReport
+	for idx, seed := range cd.SeedContacts {
+		fieldErrors := cd.listInvalidSeedIDs(idx)
+		fieldErrors = append(fieldErrors, cd.listInvalidSeedEmailReferences(idx)...)
+		fieldErrors = append(fieldErrors, listInvalidSeedCompanies(seed.Contact, companyIDs)...)
+		fieldErrors = append(fieldErrors, listInvalidSeedTimestamps(seed.Contact)...)
+		validationErrors, _ := seed.Contact.IsValid(index)
+		fieldErrors = append(fieldErrors, validationErrors...)
+
+		if len(fieldErrors) > 0 {
+			reports = append(reports, SeedReport{
+				Seed:   seed,
+				Errors: fieldErrors,
+			})
+		}
+	}
+	return reports
+}
+
+// listInvalidSeedIDs checks that the seed record has an ID and that no other seed record uses it,
+// since the API assigns IDs itself this is never caught by Contact.IsValid
+func (cd *Data) listInvalidSeedIDs(idx int) (fieldErrors models.FieldErrors) {
+	seed := cd.SeedContacts[idx]
+	if seed.Contact.ID == 0 {
+		return models.FieldErrors{models.NewMissingField("id", "It should be a/an Positive Integer")}
+	}
+	for otherIdx, other := range cd.SeedContacts {
+		if otherIdx != idx && other.Contact.ID == seed.Contact.ID {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   "id",
+				Message: fmt.Sprintf("It should be a unique value, also used by %s", other),
+				Code:    models.CodeDuplicateValue,
+			})
+		}
+	}
+	return
+}
+
+// listInvalidSeedEmailReferences checks that none of the seed record's other_emails is the primary email of
+// another record, Freshdesk requires every address to belong to a single contact
+func (cd *Data) listInvalidSeedEmailReferences(idx int) (fieldErrors models.FieldErrors) {
+	seed := cd.SeedContacts[idx]
+	for _, otherEmail := range seed.Contact.OtherEmails {
+		for otherIdx, other := range cd.SeedContacts {
+			if otherIdx != idx && other.Contact.Email == otherEmail {
+				fieldErrors = append(fieldErrors, models.FieldError{
+					Field:   "other_emails",
+					Message: fmt.Sprintf("It should be a unique value, %s is the email of %s", otherEmail, other),
+					Code:    models.CodeDuplicateValue,
+				})
+			}
+		}
+	}
+	return
+}
+
+// listInvalidSeedCompanies checks that the seed record's company_id and other_companies refer to seeded companies
+func listInvalidSeedCompanies(contact models.Contact, companyIDs map[int]bool) (fieldErrors models.FieldErrors) {
+	if contact.CompanyID != 0 && !companyIDs[contact.CompanyID] {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("company_id", fmt.Sprintf("There is no company with the id %d", contact.CompanyID)))
+	}
+	for _, otherCompany := range contact.OtherCompanies {
+		if !companyIDs[otherCompany.CompanyID] {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue("other_companies", fmt.Sprintf("There is no company with the id %d", otherCompany.CompanyID)))
+		}
+	}
+	return
+}
+
+func printSeedReports(out io.Writer, reports []SeedReport, total int) {
+	for _, report := range reports {
+		fmt.Fprintln(out, report.Seed)
+		for _, fieldError := range report.Errors {
+			fmt.Fprintf(out, "  %s: %s (%s)\n", fieldError.Field, fieldError.Message, fieldError.Code)
+		}
+	}
+	fmt.Fprintf(out, "%d of %d contact records are invalid\n", len(reports), total)
+}
+
+func addConfigValidate(cmd *cobra.Command) {
+	validate := &cobra.Command{
+		Use:   "validate",
+		Short: "Checks every seed record in the config and fixture files, exiting non-zero if any are invalid",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf := Current()
+			reports := conf.ValidateSeedData()
+			printSeedReports(cmd.OutOrStdout(), reports, len(conf.SeedContacts))
+			if len(reports) > 0 {
+				// The report has already been printed, don't repeat the usage text as well
+				cmd.SilenceUsage = true
+				return ErrConfigInvalid
+			}
+			return nil
+		},
+	}
+
+	cmd.AddCommand(validate)
+}
+
+// listInvalidSeedTimestamps checks the timestamps the API would normally set itself. Malformed ones already stop
+// the config from loading, so only the order of the two is left to check.
+func listInvalidSeedTimestamps(contact models.Contact) (fieldErrors models.FieldErrors) {
+	if !contact.UpdatedAt.IsZero() && contact.UpdatedAt.Before(contact.CreatedAt.Time) {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("updated_at", "It should not be before created_at"))
+	}
+	return
+}
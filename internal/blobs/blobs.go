@@ -0,0 +1,137 @@
+package blobs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/random"
+)
+
+var (
+	ErrBlobNotFound = fmt.Errorf("blob not found")
+)
+
+// Blob describes a stored file
+type Blob struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int       `json:"size"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Store holds uploaded files such as avatars and attachments
+type Store interface {
+	Delete(id int) error
+	Get(id int) (Blob, io.ReadCloser, error)
+	Put(name, contentType string, data io.Reader) (Blob, error)
+}
+
+// FileStore is a Store that keeps each blob as a file in a directory, next to a JSON file holding its metadata
+type FileStore struct {
+	Clock  clock.Clock
+	Dir    string
+	Random *random.Source
+
+	mu sync.Mutex
+}
+
+// NewFileStore returns a FileStore using dir, creating it if needed
+func NewFileStore(dir string, clk clock.Clock, rng *random.Source) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{
+		Clock:  clk,
+		Dir:    dir,
+		Random: rng,
+	}, nil
+}
+
+func (fileStore *FileStore) dataPath(id int) string {
+	return filepath.Join(fileStore.Dir, strconv.Itoa(id))
+}
+
+func (fileStore *FileStore) metaPath(id int) string {
+	return filepath.Join(fileStore.Dir, strconv.Itoa(id)+".json")
+}
+
+func (fileStore *FileStore) Delete(id int) error {
+	fileStore.mu.Lock()
+	defer fileStore.mu.Unlock()
+	if err := os.Remove(fileStore.metaPath(id)); err != nil {
+		return notFound(err)
+	}
+	return notFound(os.Remove(fileStore.dataPath(id)))
+}
+
+// Get returns the blob's metadata and its contents, the caller must close the reader
+func (fileStore *FileStore) Get(id int) (Blob, io.ReadCloser, error) {
+	var blob Blob
+	meta, err := os.ReadFile(fileStore.metaPath(id))
+	if err != nil {
+		return blob, nil, notFound(err)
+	}
+	if err := json.Unmarshal(meta, &blob); err != nil {
+		return blob, nil, err
+	}
+	data, err := os.Open(fileStore.dataPath(id))
+	if err != nil {
+		return blob, nil, notFound(err)
+	}
+	return blob, data, nil
+}
+
+// Put saves the data under a new, unused ID
+func (fileStore *FileStore) Put(name, contentType string, data io.Reader) (Blob, error) {
+	fileStore.mu.Lock()
+	defer fileStore.mu.Unlock()
+
+	blob := Blob{
+		Name:        filepath.Base(name),
+		ContentType: contentType,
+		CreatedAt:   fileStore.Clock.Now(),
+	}
+	for {
+		blob.ID = 1 + fileStore.Random.Intn(999999999)
+		if _, err := os.Stat(fileStore.metaPath(blob.ID)); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+	}
+
+	file, err := os.Create(fileStore.dataPath(blob.ID))
+	if err != nil {
+		return blob, err
+	}
+	size, err := io.Copy(file, data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(fileStore.dataPath(blob.ID))
+		return blob, err
+	}
+	blob.Size = int(size)
+
+	meta, err := json.Marshal(blob)
+	if err != nil {
+		return blob, err
+	}
+	return blob, os.WriteFile(fileStore.metaPath(blob.ID), meta, 0o644)
+}
+
+func notFound(err error) error {
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrBlobNotFound
+	}
+	return err
+}
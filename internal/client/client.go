@@ -0,0 +1,159 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	apiPathBase = "/api/v2"
+
+	// maxPerPage is the largest page size Freshdesk allows on list endpoints
+	maxPerPage = 100
+
+	// Freshdesk takes the API key as the basic auth username, the password is ignored
+	apiKeyPassword = "X"
+)
+
+var (
+	ErrRequestFailed = fmt.Errorf("api request failed")
+)
+
+// Client talks to the REST API of a staledesk server or a real Freshdesk account
+type Client struct {
+	APIKey  string
+	BaseURL string
+	HTTP    *http.Client
+}
+
+// APIError is returned when the server answers with a non-2xx status
+type APIError struct {
+	Status      int
+	Description string          `json:"description"`
+	Errors      []APIErrorField `json:"errors"`
+}
+
+// APIErrorField is a single field level problem reported in an APIError
+type APIErrorField struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+func (e *APIError) Error() string {
+	msgs := []string{fmt.Sprintf("%s: %d %s", ErrRequestFailed.Error(), e.Status, http.StatusText(e.Status))}
+	if e.Description != "" {
+		msgs = append(msgs, e.Description)
+	}
+	for _, field := range e.Errors {
+		msgs = append(msgs, fmt.Sprintf("%s: %s (%s)", field.Field, field.Message, field.Code))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e *APIError) Unwrap() error {
+	return ErrRequestFailed
+}
+
+// New returns a Client for the server at baseURL, i.e. "http://localhost:5000" or "https://example.freshdesk.com"
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		APIKey:  apiKey,
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		HTTP: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+	}
+}
+
+// listPages returns every record of a list endpoint matching the filters, fetching each page in turn unless the
+// filters ask for a specific page
+func listPages[T any](c *Client, path string, filters url.Values) ([]T, error) {
+	query := url.Values{}
+	for name, values := range filters {
+		query[name] = values
+	}
+	_, singlePage := query["page"]
+	if query.Get("per_page") == "" {
+		query.Set("per_page", strconv.Itoa(maxPerPage))
+	}
+	perPage, err := strconv.Atoi(query.Get("per_page"))
+	if err != nil {
+		perPage = maxPerPage
+	}
+
+	var records []T
+	for page := 1; ; page++ {
+		if !singlePage {
+			query.Set("page", strconv.Itoa(page))
+		}
+		var pageRecords []T
+		if err := c.do(http.MethodGet, path, query, nil, &pageRecords); err != nil {
+			return records, err
+		}
+		records = append(records, pageRecords...)
+		if singlePage || len(pageRecords) < perPage {
+			return records, nil
+		}
+	}
+}
+
+// do sends the request and decodes a JSON response body into out, if out is not nil
+func (c *Client) do(method, path string, query url.Values, body []byte, out interface{}) error {
+	return c.doWithContentType(method, path, query, body, "application/json", out)
+}
+
+// doWithContentType is do for request bodies that aren't JSON. A *[]byte out receives the raw response body.
+func (c *Client) doWithContentType(method, path string, query url.Values, body []byte, contentType string, out interface{}) error {
+	reqURL := c.BaseURL + apiPathBase + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	var reqBody io.Reader
+	if body != nil {
+		reqBody = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, reqURL, reqBody)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.APIKey != "" {
+		req.SetBasicAuth(c.APIKey, apiKeyPassword)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		apiErr := &APIError{Status: resp.StatusCode}
+		// Not every error has a JSON body, the status alone is still worth reporting
+		_ = json.Unmarshal(respBody, apiErr)
+		return apiErr
+	}
+	if raw, ok := out.(*[]byte); ok {
+		*raw = respBody
+		return nil
+	}
+	if out == nil || len(bytes.TrimSpace(respBody)) == 0 {
+		return nil
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,99 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path/filepath"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+// FilterContactsResp is the envelope returned by the contact search endpoint
+type FilterContactsResp struct {
+	Total   int              `json:"total"`
+	Results []models.Contact `json:"results"`
+}
+
+// ListContacts returns every contact matching the filters, fetching each page in turn unless the filters ask for
+// a specific page
+func (c *Client) ListContacts(filters url.Values) ([]models.Contact, error) {
+	return listPages[models.Contact](c, "/contacts", filters)
+}
+
+func (c *Client) GetContact(id int) (models.Contact, error) {
+	var contact models.Contact
+	err := c.do(http.MethodGet, fmt.Sprintf("/contacts/%d", id), nil, nil, &contact)
+	return contact, err
+}
+
+// CreateContact sends body as is, so fields the models package doesn't know about still reach the server
+func (c *Client) CreateContact(body []byte) (models.Contact, error) {
+	var contact models.Contact
+	err := c.do(http.MethodPost, "/contacts", nil, body, &contact)
+	return contact, err
+}
+
+// UpdateContact sends body as is, only the fields it contains are changed
+func (c *Client) UpdateContact(id int, body []byte) (models.Contact, error) {
+	var contact models.Contact
+	err := c.do(http.MethodPut, fmt.Sprintf("/contacts/%d", id), nil, body, &contact)
+	return contact, err
+}
+
+func (c *Client) DeleteContact(id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/contacts/%d", id), nil, nil, nil)
+}
+
+// SearchContacts runs a filter query such as "unique_external_id:'abc' OR unique_external_id:'def'"
+func (c *Client) SearchContacts(query string) (FilterContactsResp, error) {
+	var resp FilterContactsResp
+	params := url.Values{}
+	params.Set("query", `"`+query+`"`)
+	err := c.do(http.MethodGet, "/search/contacts", params, nil, &resp)
+	return resp, err
+}
+
+// ImportContacts uploads a CSV file to start a background import. mapping links a contact field to a CSV column,
+// given as a 0 based index or a header name; if empty, the server matches header names to fields.
+func (c *Client) ImportContacts(fileName string, csvData io.Reader, mapping map[string]string) (int, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filepath.Base(fileName))
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.Copy(part, csvData); err != nil {
+		return 0, err
+	}
+	for field, column := range mapping {
+		if err := writer.WriteField(fmt.Sprintf("contact_fields[%s]", field), column); err != nil {
+			return 0, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+
+	var resp struct {
+		ID int `json:"id"`
+	}
+	err = c.doWithContentType(http.MethodPost, "/contacts/imports", nil, body.Bytes(), writer.FormDataContentType(), &resp)
+	return resp.ID, err
+}
+
+func (c *Client) GetContactImport(id int) (models.ContactImport, error) {
+	var contactImport models.ContactImport
+	err := c.do(http.MethodGet, fmt.Sprintf("/contacts/imports/%d", id), nil, nil, &contactImport)
+	return contactImport, err
+}
+
+// GetContactImportFailures downloads the CSV report of the rows that failed to import
+func (c *Client) GetContactImportFailures(id int) ([]byte, error) {
+	var report []byte
+	err := c.do(http.MethodGet, fmt.Sprintf("/contacts/imports/%d/failures", id), nil, nil, &report)
+	return report, err
+}
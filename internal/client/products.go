@@ -0,0 +1,65 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+func (c *Client) ListProducts() ([]models.Product, error) {
+	return listPages[models.Product](c, "/products", url.Values{})
+}
+
+func (c *Client) GetProduct(id int) (models.Product, error) {
+	var product models.Product
+	err := c.do(http.MethodGet, fmt.Sprintf("/products/%d", id), nil, nil, &product)
+	return product, err
+}
+
+// CreateProduct sends body as is, so fields the models package doesn't know about still reach the server
+func (c *Client) CreateProduct(body []byte) (models.Product, error) {
+	var product models.Product
+	err := c.do(http.MethodPost, "/products", nil, body, &product)
+	return product, err
+}
+
+// UpdateProduct sends body as is, only the fields it contains are changed
+func (c *Client) UpdateProduct(id int, body []byte) (models.Product, error) {
+	var product models.Product
+	err := c.do(http.MethodPut, fmt.Sprintf("/products/%d", id), nil, body, &product)
+	return product, err
+}
+
+func (c *Client) DeleteProduct(id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/products/%d", id), nil, nil, nil)
+}
+
+func (c *Client) ListEmailConfigs() ([]models.EmailConfig, error) {
+	return listPages[models.EmailConfig](c, "/email_configs", url.Values{})
+}
+
+func (c *Client) GetEmailConfig(id int) (models.EmailConfig, error) {
+	var emailConfig models.EmailConfig
+	err := c.do(http.MethodGet, fmt.Sprintf("/email_configs/%d", id), nil, nil, &emailConfig)
+	return emailConfig, err
+}
+
+// CreateEmailConfig sends body as is, so fields the models package doesn't know about still reach the server
+func (c *Client) CreateEmailConfig(body []byte) (models.EmailConfig, error) {
+	var emailConfig models.EmailConfig
+	err := c.do(http.MethodPost, "/email_configs", nil, body, &emailConfig)
+	return emailConfig, err
+}
+
+// UpdateEmailConfig sends body as is, only the fields it contains are changed
+func (c *Client) UpdateEmailConfig(id int, body []byte) (models.EmailConfig, error) {
+	var emailConfig models.EmailConfig
+	err := c.do(http.MethodPut, fmt.Sprintf("/email_configs/%d", id), nil, body, &emailConfig)
+	return emailConfig, err
+}
+
+func (c *Client) DeleteEmailConfig(id int) error {
+	return c.do(http.MethodDelete, fmt.Sprintf("/email_configs/%d", id), nil, nil, nil)
+}
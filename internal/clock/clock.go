@@ -0,0 +1,50 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is where every timestamp the server hands out comes from, so tests can control them
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the real wall clock
+type System struct{}
+
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Frozen is a clock that only moves when it is told to
+type Frozen struct {
+	mu  sync.RWMutex
+	now time.Time
+}
+
+// NewFrozen returns a Frozen clock stopped at now
+func NewFrozen(now time.Time) *Frozen {
+	return &Frozen{now: now.UTC()}
+}
+
+func (frozen *Frozen) Now() time.Time {
+	frozen.mu.RLock()
+	defer frozen.mu.RUnlock()
+	return frozen.now
+}
+
+// Advance moves the clock forward by d (or back, if d is negative) and returns the new time
+func (frozen *Frozen) Advance(d time.Duration) time.Time {
+	frozen.mu.Lock()
+	defer frozen.mu.Unlock()
+	frozen.now = frozen.now.Add(d)
+	return frozen.now
+}
+
+// Set stops the clock at now
+func (frozen *Frozen) Set(now time.Time) {
+	frozen.mu.Lock()
+	defer frozen.mu.Unlock()
+	frozen.now = now.UTC()
+}
@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// Admin serves the endpoints that control the server itself rather than emulating Freshdesk
+type Admin struct {
+	Clock clock.Clock
+}
+
+type ClockResp struct {
+	Frozen bool        `json:"frozen"`
+	Now    models.Time `json:"now"`
+}
+
+type setClockReq struct {
+	// Advance is a Go duration (i.e. "90m" or "-1h") to move the clock by
+	Advance string `json:"advance"`
+	// Now is an RFC3339 time to stop the clock at
+	Now string `json:"now"`
+}
+
+func NewAdminController(clk clock.Clock) *Admin {
+	return &Admin{
+		Clock: clk,
+	}
+}
+
+// GetClock returns the time the server currently uses for timestamps
+func (adminControl *Admin) GetClock(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, adminControl.clockResp())
+}
+
+// SetClock moves a frozen clock, either by a duration or to a fixed time. The real clock can't be changed.
+func (adminControl *Admin) SetClock(ctx *gin.Context) {
+	frozen, ok := adminControl.Clock.(*clock.Frozen)
+	if !ok {
+		ctx.JSON(http.StatusConflict, ErrorResp{
+			Description: "The clock can only be changed when the server is started with --frozen-time",
+			Errors: []ErrorDetails{
+				{
+					Field:   "",
+					Message: "clock is not frozen",
+					Code:    "clock_not_frozen",
+				},
+			},
+		})
+		return
+	}
+
+	var req setClockReq
+	_, fieldErrors, err := bindStrictJSON(ctx, &req, nil)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	switch {
+	case req.Advance != "" && req.Now != "":
+		respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("advance", "Only one of advance or now can be given")})
+		return
+	case req.Advance != "":
+		duration, err := time.ParseDuration(req.Advance)
+		if err != nil {
+			respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("advance", "It should be a duration, i.e. 90m or 2h30m")})
+			return
+		}
+		frozen.Advance(duration)
+	case req.Now != "":
+		now, err := time.Parse(time.RFC3339, req.Now)
+		if err != nil {
+			respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("now", "It should be an RFC3339 time, i.e. 2023-01-02T15:04:05Z")})
+			return
+		}
+		frozen.Set(now)
+	default:
+		respondValidationFailed(ctx, models.FieldErrors{models.NewMissingField("advance", "One of advance or now is required")})
+		return
+	}
+	ctx.JSON(http.StatusOK, adminControl.clockResp())
+}
+
+func (adminControl *Admin) clockResp() ClockResp {
+	_, frozen := adminControl.Clock.(*clock.Frozen)
+	return ClockResp{
+		Frozen: frozen,
+		Now:    models.NewTime(adminControl.Clock.Now()),
+	}
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	FormNameAvatar = "avatar"
+
+	// MaxAvatarSize is the largest avatar Freshdesk accepts, in bytes
+	MaxAvatarSize = 5 * 1024 * 1024
+)
+
+var (
+	// avatarContentTypes are the image types accepted as an avatar, they are detected from the file's contents
+	avatarContentTypes = map[string]bool{
+		"image/gif":  true,
+		"image/jpeg": true,
+		"image/png":  true,
+	}
+)
+
+// readAvatar returns the avatar file part of a multipart request, or nil if there isn't one. It isn't stored
+// until the rest of the request is known to be valid.
+func readAvatar(ctx *gin.Context) (*fileUpload, models.FieldErrors) {
+	if ctx.ContentType() != gin.MIMEMultipartPOSTForm {
+		return nil, nil
+	}
+	header, err := ctx.FormFile(FormNameAvatar)
+	if err == http.ErrMissingFile {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, models.FieldErrors{models.NewInvalidValue(FormNameAvatar, "Unable to read the uploaded file")}
+	}
+	upload, err := readUpload(header, MaxAvatarSize)
+	if err == ErrUploadTooLarge {
+		return nil, models.FieldErrors{models.NewInvalidValue(FormNameAvatar, fmt.Sprintf("It should not be more than %d MB in size", MaxAvatarSize/1024/1024))}
+	}
+	if err != nil {
+		return nil, models.FieldErrors{models.NewInvalidValue(FormNameAvatar, "Unable to read the uploaded file")}
+	}
+	if !avatarContentTypes[upload.contentType] {
+		return nil, models.FieldErrors{models.NewInvalidValue(FormNameAvatar, "It should be in *.jpg, *.jpeg, *.png or *.gif format")}
+	}
+	return upload, nil
+}
+
+// storeAvatar saves the upload as a blob and returns the avatar that points at it
+func (contControl *Contacts) storeAvatar(ctx *gin.Context, upload *fileUpload) (models.ContactAvatar, error) {
+	blob, err := contControl.Blobs.Put(upload.name, upload.contentType, bytes.NewReader(upload.data))
+	if err != nil {
+		return models.ContactAvatar{}, err
+	}
+	created := models.NewTime(blob.CreatedAt)
+	return models.ContactAvatar{
+		AvatarUrl:   fileURL(ctx, blob.ID),
+		ContentType: blob.ContentType,
+		Id:          blob.ID,
+		Name:        blob.Name,
+		Size:        blob.Size,
+		CreatedAt:   created,
+		UpdatedAt:   created,
+	}, nil
+}
+
+// DeleteAvatar removes a contact's avatar
+func (contControl *Contacts) DeleteAvatar(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+
+	contControl.mu.Lock()
+	defer contControl.mu.Unlock()
+
+	contact, err := contControl.Store.GetContact(intID)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	if contact.Avatar.Id == 0 {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+
+	avatarID := contact.Avatar.Id
+	contact.Avatar = models.ContactAvatar{}
+	if err := contControl.Store.PutContact(contact); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	deleteBlob(contControl.Blobs, avatarID)
+	ctx.JSON(http.StatusNoContent, nil)
+}
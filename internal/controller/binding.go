@@ -0,0 +1,403 @@
+package controller
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	descValidationFailed = "Validation failed"
+	descInvalidJSON      = "Request body has invalid json format"
+)
+
+var (
+	ErrInvalidJSON      = fmt.Errorf("request body is not valid json")
+	ErrInvalidMultipart = fmt.Errorf("request body is not valid multipart form data")
+)
+
+var (
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// bindRequest decodes a JSON or multipart/form-data request body into target, depending on its content type
+func bindRequest(ctx *gin.Context, target interface{}, readOnly []string) (fieldPresence, models.FieldErrors, error) {
+	if ctx.ContentType() == gin.MIMEMultipartPOSTForm {
+		return bindMultipartForm(ctx, target, readOnly)
+	}
+	return bindStrictJSON(ctx, target, readOnly)
+}
+
+// bindMultipartForm decodes the text parts of a multipart/form-data body the way Freshdesk reads them:
+// list fields are sent as "tags[]" (repeated), and nested fields as "custom_fields[benefit]".
+// File parts are left for the caller to read.
+func bindMultipartForm(ctx *gin.Context, target interface{}, readOnly []string) (fieldPresence, models.FieldErrors, error) {
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		return nil, nil, ErrInvalidMultipart
+	}
+
+	skip := map[string]bool{}
+	for _, field := range readOnly {
+		skip[field] = true
+	}
+	keys := make([]string, 0, len(form.Value))
+	for key := range form.Value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	present := fieldPresence{}
+	var fieldErrors models.FieldErrors
+	dst := reflect.ValueOf(target).Elem()
+	for _, key := range keys {
+		path := formKeyToPath(key)
+		if skip[path] || !isFieldPath(dst.Type(), path) {
+			fieldErrors = append(fieldErrors, models.NewInvalidField(path))
+			continue
+		}
+		if fieldError := setFieldFromStrings(dst, path, form.Value[key]); fieldError != nil {
+			fieldErrors = append(fieldErrors, *fieldError)
+			continue
+		}
+		present[path] = true
+	}
+	return present, fieldErrors, nil
+}
+
+// formKeyToPath converts a form key such as "custom_fields[benefit]" or "tags[]" to a dotted json path
+func formKeyToPath(key string) string {
+	key = strings.TrimSuffix(key, "[]")
+	key = strings.ReplaceAll(key, "][", ".")
+	key = strings.ReplaceAll(key, "[", ".")
+	return strings.TrimSuffix(key, "]")
+}
+
+// fieldPresence records which json paths were sent in a request body, including ones explicitly set to null or "".
+// Nested keys use a dotted path, i.e. "custom_fields.benefit".
+type fieldPresence map[string]bool
+
+// bindStrictJSON decodes the request body into target one field at a time, the same way Freshdesk does, so that
+// every unknown, read-only, or mistyped field can be reported in a single response.
+// A nil error with a non-empty FieldErrors means the body was well-formed JSON but failed field validation.
+func bindStrictJSON(ctx *gin.Context, target interface{}, readOnly []string) (fieldPresence, models.FieldErrors, error) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, nil, ErrInvalidJSON
+	}
+
+	skip := map[string]bool{}
+	for _, field := range readOnly {
+		skip[field] = true
+	}
+	present := fieldPresence{}
+	fieldErrors := decodeFields(raw, reflect.ValueOf(target).Elem(), skip, present, "")
+	return present, fieldErrors, nil
+}
+
+// decodeFields assigns each raw JSON value to the struct field carrying the matching json tag.
+// Nested structs (such as custom_fields) are decoded recursively so unknown keys inside them are caught as well.
+// A null value leaves the field at its zero value, which is how a client clears it.
+// Errors for nested fields name their dotted path, i.e. "custom_fields.benefit".
+func decodeFields(raw map[string]json.RawMessage, dst reflect.Value, skip map[string]bool, present fieldPresence, prefix string) (fieldErrors models.FieldErrors) {
+	fields := jsonFieldIndex(dst.Type())
+
+	// Sort the keys so the errors are reported in a stable order
+	keys := make([]string, 0, len(raw))
+	for key := range raw {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := raw[key]
+		path := prefix + key
+		idx, known := fields[key]
+		if !known || skip[key] {
+			fieldErrors = append(fieldErrors, models.NewInvalidField(path))
+			continue
+		}
+		field := dst.Field(idx)
+
+		if isNestedStruct(field.Type()) && jsonTypeName(value) == "key/value pair" {
+			var nested map[string]json.RawMessage
+			if err := json.Unmarshal(value, &nested); err == nil {
+				fieldErrors = append(fieldErrors, decodeFields(nested, field, map[string]bool{}, present, path+".")...)
+				continue
+			}
+		}
+
+		target := reflect.New(field.Type())
+		if err := json.Unmarshal(value, target.Interface()); err != nil {
+			// A string that doesn't parse as a typed value, such as a timestamp, is the right type with a bad value
+			if jsonTypeName(value) == goTypeName(field.Type()) {
+				fieldErrors = append(fieldErrors, models.NewInvalidValue(path, err.Error()))
+				continue
+			}
+			fieldErrors = append(fieldErrors, models.NewDatatypeMismatch(path, jsonTypeName(value), goTypeName(field.Type())))
+			continue
+		}
+		field.Set(target.Elem())
+		present[path] = true
+	}
+	return
+}
+
+// applyPresentFields copies every field of src that was sent in the request onto dst.
+// Nested structs are merged key by key, so updating one custom field leaves the others untouched.
+// It returns true if any field was copied.
+func applyPresentFields(dst, src interface{}, present fieldPresence) bool {
+	return applyPresentValues(reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem(), present, "")
+}
+
+func applyPresentValues(dst, src reflect.Value, present fieldPresence, prefix string) (applied bool) {
+	for name, idx := range jsonFieldIndex(dst.Type()) {
+		path := prefix + name
+		switch {
+		case present[path]:
+			dst.Field(idx).Set(src.Field(idx))
+			applied = true
+		case isNestedStruct(dst.Field(idx).Type()):
+			if applyPresentValues(dst.Field(idx), src.Field(idx), present, path+".") {
+				applied = true
+			}
+		}
+	}
+	return
+}
+
+// mergeFieldErrors appends the record level validation errors to the decoding errors,
+// skipping any field that already failed decoding so a single bad value isn't reported twice
+func mergeFieldErrors(decodeErrors, validationErrors models.FieldErrors) models.FieldErrors {
+	reported := map[string]bool{}
+	for _, fieldError := range decodeErrors {
+		reported[fieldError.Field] = true
+	}
+	for _, fieldError := range validationErrors {
+		if !reported[fieldError.Field] {
+			decodeErrors = append(decodeErrors, fieldError)
+		}
+	}
+	return decodeErrors
+}
+
+// jsonFieldIndex maps each json tag name of a struct type to its field index
+func jsonFieldIndex(t reflect.Type) map[string]int {
+	fields := map[string]int{}
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("json")
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		fields[name] = i
+	}
+	return fields
+}
+
+// jsonTypeName returns the Freshdesk name for the type of a raw JSON value
+func jsonTypeName(value json.RawMessage) string {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 {
+		return "Null"
+	}
+	switch trimmed[0] {
+	case '"':
+		return "String"
+	case 't', 'f':
+		return "Boolean"
+	case '[':
+		return "Array"
+	case '{':
+		return "key/value pair"
+	case 'n':
+		return "Null"
+	default:
+		return "Number"
+	}
+}
+
+// goTypeName returns the Freshdesk name for the JSON type a struct field expects
+func goTypeName(t reflect.Type) string {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "String"
+	case reflect.Bool:
+		return "Boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "Integer"
+	case reflect.Float32, reflect.Float64:
+		return "Number"
+	case reflect.Slice, reflect.Array:
+		return "Array"
+	}
+	if t.Kind() == reflect.Struct && !isNestedStruct(t) {
+		return "String"
+	}
+	return "key/value pair"
+}
+
+// respondInvalidJSON sends the response Freshdesk uses for a body that can't be parsed at all
+func respondInvalidJSON(ctx *gin.Context) {
+	ctx.JSON(http.StatusBadRequest, ErrorResp{
+		Description: descInvalidJSON,
+		Errors: []ErrorDetails{
+			{
+				Field:   "",
+				Message: descInvalidJSON,
+				Code:    "invalid_json",
+			},
+		},
+	})
+}
+
+// respondValidationFailed sends a Freshdesk style validation error listing every field problem.
+// Conflicts on unique values get a 409, anything else is a 400.
+func respondValidationFailed(ctx *gin.Context, fieldErrors models.FieldErrors) {
+	status := http.StatusBadRequest
+	if fieldErrors.OnlyCode(models.CodeDuplicateValue) {
+		status = http.StatusConflict
+	}
+
+	respErrorDetails := make([]ErrorDetails, 0, len(fieldErrors))
+	for _, fieldError := range fieldErrors {
+		respErrorDetails = append(respErrorDetails, ErrorDetails(fieldError))
+	}
+	ctx.JSON(status, ErrorResp{
+		Description: descValidationFailed,
+		Errors:      respErrorDetails,
+	})
+}
+
+// setFieldFromString assigns a text value to the struct field at the given dotted json path
+func setFieldFromString(dst reflect.Value, path, value string) *models.FieldError {
+	name, rest, nested := strings.Cut(path, ".")
+	idx, known := jsonFieldIndex(dst.Type())[name]
+	if !known {
+		fieldError := models.NewInvalidField(path)
+		return &fieldError
+	}
+	field := dst.Field(idx)
+	if nested {
+		if !isNestedStruct(field.Type()) {
+			fieldError := models.NewInvalidField(path)
+			return &fieldError
+		}
+		return setFieldFromString(field, rest, value)
+	}
+
+	mismatch := func() *models.FieldError {
+		fieldError := models.NewDatatypeMismatch(path, "String", goTypeName(field.Type()))
+		return &fieldError
+	}
+	if unmarshaler, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := unmarshaler.UnmarshalText([]byte(value)); err != nil {
+			fieldError := models.NewInvalidValue(path, err.Error())
+			return &fieldError
+		}
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return mismatch()
+		}
+		field.SetBool(parsed)
+	case reflect.Int, reflect.Int64:
+		parsed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return mismatch()
+		}
+		field.SetInt(parsed)
+	case reflect.Ptr:
+		if field.Type().Elem().Kind() != reflect.Bool {
+			return mismatch()
+		}
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			return mismatch()
+		}
+		field.Set(reflect.ValueOf(&parsed))
+	case reflect.Slice:
+		elemKind := field.Type().Elem().Kind()
+		if elemKind != reflect.String && elemKind != reflect.Interface {
+			return mismatch()
+		}
+		items := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, item := range strings.Split(value, ",") {
+			items = reflect.Append(items, reflect.ValueOf(strings.TrimSpace(item)))
+		}
+		field.Set(items)
+	default:
+		return mismatch()
+	}
+	return nil
+}
+
+// setFieldFromStrings is setFieldFromString for a form field that may be repeated, each value becomes one item
+// of a list field. Only the first value is used for any other type of field.
+func setFieldFromStrings(dst reflect.Value, path string, values []string) *models.FieldError {
+	if len(values) == 0 {
+		return nil
+	}
+	name, rest, nested := strings.Cut(path, ".")
+	idx := jsonFieldIndex(dst.Type())[name]
+	field := dst.Field(idx)
+	if nested {
+		return setFieldFromStrings(field, rest, values)
+	}
+	if field.Kind() != reflect.Slice {
+		return setFieldFromString(dst, path, values[0])
+	}
+	elemKind := field.Type().Elem().Kind()
+	if elemKind != reflect.String && elemKind != reflect.Interface {
+		fieldError := models.NewDatatypeMismatch(path, "String", goTypeName(field.Type()))
+		return &fieldError
+	}
+	items := reflect.MakeSlice(field.Type(), 0, len(values))
+	for _, value := range values {
+		items = reflect.Append(items, reflect.ValueOf(value))
+	}
+	field.Set(items)
+	return nil
+}
+
+// isFieldPath reports whether the dotted json path names a field of the struct type
+func isFieldPath(t reflect.Type, path string) bool {
+	name, rest, nested := strings.Cut(path, ".")
+	idx, known := jsonFieldIndex(t)[name]
+	if !known {
+		return false
+	}
+	if !nested {
+		return true
+	}
+	field := t.Field(idx).Type
+	return isNestedStruct(field) && isFieldPath(field, rest)
+}
+
+// isNestedStruct reports whether values of the type are JSON objects whose keys are fields in their own right,
+// rather than a single value such as a timestamp
+func isNestedStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && !t.Implements(textUnmarshalerType) && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
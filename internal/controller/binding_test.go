@@ -0,0 +1,194 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+type bindingTestFields struct {
+	Benefit string `json:"benefit,omitempty"`
+	Phone1  string `json:"phone_1,omitempty"`
+}
+
+type bindingTestRecord struct {
+	Active       *bool             `json:"active,omitempty"`
+	CustomFields bindingTestFields `json:"custom_fields,omitempty"`
+	ID           int               `json:"id,omitempty"`
+	Name         string            `json:"name,omitempty"`
+	Tags         []interface{}     `json:"tags,omitempty"`
+	UpdatedAt    models.Time       `json:"updated_at"`
+}
+
+func newJSONContext(body string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	ctx.Request.Header.Set("Content-Type", gin.MIMEJSON)
+	return ctx
+}
+
+func TestBindStrictJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantErrors  models.FieldErrors
+		wantPresent fieldPresence
+	}{
+		{
+			name:        "valid fields",
+			body:        `{"name":"Ann","tags":["a"],"custom_fields":{"benefit":"CSNP"}}`,
+			wantPresent: fieldPresence{"custom_fields.benefit": true, "name": true, "tags": true},
+		},
+		{
+			name:        "unknown field",
+			body:        `{"name":"Ann","nickname":"A"}`,
+			wantErrors:  models.FieldErrors{models.NewInvalidField("nickname")},
+			wantPresent: fieldPresence{"name": true},
+		},
+		{
+			name:        "read only field",
+			body:        `{"id":5,"name":"Ann"}`,
+			wantErrors:  models.FieldErrors{models.NewInvalidField("id")},
+			wantPresent: fieldPresence{"name": true},
+		},
+		{
+			name:        "unknown nested field",
+			body:        `{"custom_fields":{"benefit":"CSNP","plan":"gold"}}`,
+			wantErrors:  models.FieldErrors{models.NewInvalidField("custom_fields.plan")},
+			wantPresent: fieldPresence{"custom_fields.benefit": true},
+		},
+		{
+			name:       "mistyped field",
+			body:       `{"name":5,"active":"yes"}`,
+			wantErrors: models.FieldErrors{models.NewDatatypeMismatch("active", "String", "Boolean"), models.NewDatatypeMismatch("name", "Number", "String")},
+		},
+		{
+			name:       "mistyped nested field",
+			body:       `{"custom_fields":{"benefit":true}}`,
+			wantErrors: models.FieldErrors{models.NewDatatypeMismatch("custom_fields.benefit", "Boolean", "String")},
+		},
+		{
+			name:        "null values",
+			body:        `{"name":null,"custom_fields":null}`,
+			wantPresent: fieldPresence{"custom_fields": true, "name": true},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var record bindingTestRecord
+			present, fieldErrors, err := bindStrictJSON(newJSONContext(test.body), &record, []string{"id"})
+			if err != nil {
+				t.Fatalf("bindStrictJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(fieldErrors, test.wantErrors) {
+				t.Errorf("bindStrictJSON() field errors = %v, want %v", fieldErrors, test.wantErrors)
+			}
+			if test.wantPresent == nil {
+				test.wantPresent = fieldPresence{}
+			}
+			if !reflect.DeepEqual(present, test.wantPresent) {
+				t.Errorf("bindStrictJSON() present = %v, want %v", present, test.wantPresent)
+			}
+		})
+	}
+}
+
+func TestBindStrictJSONInvalidValue(t *testing.T) {
+	var record bindingTestRecord
+	_, fieldErrors, err := bindStrictJSON(newJSONContext(`{"updated_at":"2023-13-01"}`), &record, nil)
+	if err != nil {
+		t.Fatalf("bindStrictJSON() error = %v", err)
+	}
+	if len(fieldErrors) != 1 || fieldErrors[0].Field != "updated_at" || fieldErrors[0].Code != models.CodeInvalidValue {
+		t.Errorf("bindStrictJSON() field errors = %v, want one invalid_value for updated_at", fieldErrors)
+	}
+}
+
+func TestBindStrictJSONInvalidJSON(t *testing.T) {
+	for _, body := range []string{`{"name":`, `["name"]`, ``} {
+		var record bindingTestRecord
+		if _, _, err := bindStrictJSON(newJSONContext(body), &record, nil); err != ErrInvalidJSON {
+			t.Errorf("bindStrictJSON(%q) error = %v, want ErrInvalidJSON", body, err)
+		}
+	}
+}
+
+func TestApplyPresentFields(t *testing.T) {
+	active := true
+	existing := bindingTestRecord{
+		Active:       &active,
+		CustomFields: bindingTestFields{Benefit: "CSNP", Phone1: "555"},
+		Name:         "Ann",
+		Tags:         []interface{}{"a"},
+	}
+	tests := []struct {
+		name        string
+		body        string
+		want        bindingTestRecord
+		wantApplied bool
+	}{
+		{
+			name: "absent fields are kept",
+			body: `{}`,
+			want: existing,
+		},
+		{
+			name:        "null clears a field",
+			body:        `{"active":null,"tags":null}`,
+			want:        bindingTestRecord{CustomFields: existing.CustomFields, Name: "Ann"},
+			wantApplied: true,
+		},
+		{
+			name:        "empty string clears a field",
+			body:        `{"name":""}`,
+			want:        bindingTestRecord{Active: &active, CustomFields: existing.CustomFields, Tags: existing.Tags},
+			wantApplied: true,
+		},
+		{
+			name:        "nested fields are merged",
+			body:        `{"custom_fields":{"benefit":"PPO"}}`,
+			want:        bindingTestRecord{Active: &active, CustomFields: bindingTestFields{Benefit: "PPO", Phone1: "555"}, Name: "Ann", Tags: existing.Tags},
+			wantApplied: true,
+		},
+		{
+			name:        "null clears every nested field",
+			body:        `{"custom_fields":null}`,
+			want:        bindingTestRecord{Active: &active, Name: "Ann", Tags: existing.Tags},
+			wantApplied: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var update bindingTestRecord
+			present, fieldErrors, err := bindStrictJSON(newJSONContext(test.body), &update, nil)
+			if err != nil || len(fieldErrors) > 0 {
+				t.Fatalf("bindStrictJSON() = %v, %v", fieldErrors, err)
+			}
+			got := existing
+			if applied := applyPresentFields(&got, &update, present); applied != test.wantApplied {
+				t.Errorf("applyPresentFields() = %v, want %v", applied, test.wantApplied)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("applyPresentFields() record = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMergeFieldErrors(t *testing.T) {
+	decodeErrors := models.FieldErrors{models.NewDatatypeMismatch("email", "Number", "String")}
+	validationErrors := models.FieldErrors{
+		models.NewInvalidValue("email", "It should be in the 'valid email address' format"),
+		models.NewMissingField("phone", "Please fill at least 1 of email, mobile, phone, twitter_id, unique_external_id fields"),
+	}
+	want := models.FieldErrors{decodeErrors[0], validationErrors[1]}
+	if got := mergeFieldErrors(decodeErrors, validationErrors); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeFieldErrors() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,156 @@
+package controller
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ParamNameCannedResponseFolderID = "id"
+	ParamNameCannedResponseID       = "id"
+)
+
+// CannedResponses serves the canned response folders and the responses in them
+type CannedResponses struct {
+	Folders   func() []models.CannedResponseFolder
+	Responses func() []models.CannedResponse
+	Store     store.ContactStore
+}
+
+// RenderedCannedResponse is a canned response's content with its placeholders filled in
+type RenderedCannedResponse struct {
+	Content     string `json:"content"`
+	ContentHTML string `json:"content_html"`
+}
+
+type renderCannedResponseReq struct {
+	// RequesterID is the contact the ticket.requester placeholders are filled in from
+	RequesterID int `json:"requester_id"`
+}
+
+func NewCannedResponsesController(folders func() []models.CannedResponseFolder, responses func() []models.CannedResponse, contactStore store.ContactStore) *CannedResponses {
+	return &CannedResponses{
+		Folders:   folders,
+		Responses: responses,
+		Store:     contactStore,
+	}
+}
+
+// folderResponses returns the responses in a folder
+func (cannedControl *CannedResponses) folderResponses(folderID int) []models.CannedResponse {
+	responses := []models.CannedResponse{}
+	for _, response := range cannedControl.Responses() {
+		if response.FolderID == folderID {
+			responses = append(responses, response)
+		}
+	}
+	return responses
+}
+
+// findFolder returns the folder with the ID in the path, responding with a 404 if there isn't one
+func (cannedControl *CannedResponses) findFolder(ctx *gin.Context) (models.CannedResponseFolder, bool) {
+	intID, err := strconv.Atoi(ctx.Param(ParamNameCannedResponseFolderID))
+	if err == nil {
+		for _, folder := range cannedControl.Folders() {
+			if folder.ID == intID {
+				return folder, true
+			}
+		}
+	}
+	ctx.JSON(http.StatusNotFound, nil)
+	return models.CannedResponseFolder{}, false
+}
+
+// findResponse returns the response with the ID in the path, responding with a 404 if there isn't one
+func (cannedControl *CannedResponses) findResponse(ctx *gin.Context) (models.CannedResponse, bool) {
+	intID, err := strconv.Atoi(ctx.Param(ParamNameCannedResponseID))
+	if err == nil {
+		for _, response := range cannedControl.Responses() {
+			if response.ID == intID {
+				return response, true
+			}
+		}
+	}
+	ctx.JSON(http.StatusNotFound, nil)
+	return models.CannedResponse{}, false
+}
+
+// ListFolders lists the folders with the number of responses in each
+func (cannedControl *CannedResponses) ListFolders(ctx *gin.Context) {
+	folders := []models.CannedResponseFolder{}
+	for _, folder := range cannedControl.Folders() {
+		folder.ResponsesCount = len(cannedControl.folderResponses(folder.ID))
+		folders = append(folders, folder)
+	}
+	ctx.JSON(http.StatusOK, folders)
+}
+
+// GetFolder returns a folder along with the responses in it
+func (cannedControl *CannedResponses) GetFolder(ctx *gin.Context) {
+	folder, ok := cannedControl.findFolder(ctx)
+	if !ok {
+		return
+	}
+	folder.CannedResponses = cannedControl.folderResponses(folder.ID)
+	folder.ResponsesCount = len(folder.CannedResponses)
+	ctx.JSON(http.StatusOK, folder)
+}
+
+// ListFolderResponses lists the responses in a folder
+func (cannedControl *CannedResponses) ListFolderResponses(ctx *gin.Context) {
+	folder, ok := cannedControl.findFolder(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, cannedControl.folderResponses(folder.ID))
+}
+
+func (cannedControl *CannedResponses) GetResponse(ctx *gin.Context) {
+	response, ok := cannedControl.findResponse(ctx)
+	if !ok {
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// Render fills in a response's placeholders the way they would be when it is applied to a reply, with the
+// ticket.requester placeholders taken from the contact given as requester_id
+func (cannedControl *CannedResponses) Render(ctx *gin.Context) {
+	response, ok := cannedControl.findResponse(ctx)
+	if !ok {
+		return
+	}
+	var req renderCannedResponseReq
+	_, fieldErrors, err := bindStrictJSON(ctx, &req, nil)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	values := map[string]string{}
+	if req.RequesterID != 0 {
+		contact, err := cannedControl.Store.GetContact(req.RequesterID)
+		if err == store.ErrRecordNotFound {
+			respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("requester_id", "There is no contact matching the given requester_id")})
+			return
+		}
+		if err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
+		values = models.RequesterPlaceholders(contact)
+	}
+	contentHTML := models.RenderPlaceholders(response.ContentHTML, values)
+	ctx.JSON(http.StatusOK, RenderedCannedResponse{
+		Content:     models.HTMLToText(contentHTML),
+		ContentHTML: contentHTML,
+	})
+}
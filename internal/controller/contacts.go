@@ -2,14 +2,18 @@ package controller
 
 import (
 	"fmt"
-	"math/rand"
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
+	"sync"
 
-	"github.com/SkyMack/staledesk/config"
+	"github.com/SkyMack/staledesk/internal/blobs"
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/jobs"
 	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/random"
+	"github.com/SkyMack/staledesk/internal/search"
+	"github.com/SkyMack/staledesk/internal/store"
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 )
@@ -18,8 +22,52 @@ const (
 	ParamNameContactID = "id"
 )
 
+var (
+	// contactReadOnlyFields are set by the server and rejected as invalid_field when sent by a client
+	// The avatar can only be set with a multipart file upload
+	contactReadOnlyFields = []string{"avatar", "blocked", "created_at", "deleted", "id", "updated_at"}
+
+	// contactSearchSchema lists the fields the contact filter query can use, every custom field is searchable too
+	contactSearchSchema = search.MustSchema(models.Contact{}, map[string]string{
+		"active":             "active",
+		"company_id":         "company_id",
+		"created_at":         "created_at",
+		"email":              "email",
+		"language":           "language",
+		"mobile":             "mobile",
+		"phone":              "phone",
+		"tag":                "tags",
+		"time_zone":          "time_zone",
+		"twitter_id":         "twitter_id",
+		"unique_external_id": "unique_external_id",
+		"updated_at":         "updated_at",
+	}, "custom_fields")
+
+	// contactHardDeleteParams are the query params accepted by the contact hard delete
+	contactHardDeleteParams = []string{"force"}
+
+	// contactListParams are the query params accepted by the contact list
+	contactListParams = []string{"_updated_since", "company_id", "email", "mobile", "page", "per_page", "phone", "state", "unique_external_id", "updated_since"}
+)
+
 type Contacts struct {
-	CurrentContacts map[int]models.Contact
+	Blobs  blobs.Store
+	Clock  clock.Clock
+	Jobs   *jobs.Runner
+	Random *random.Source
+	Store  store.Store
+
+	// mu serializes the validate-then-write sequences so two requests can't both claim the same unique value
+	mu sync.Mutex
+	// writes counts the contact creates, updates and deletes, so a contactIndex can tell when it is out of date
+	writes uint64
+}
+
+// contactIndex is an index of the stored contacts that a series of creates can share, it is rebuilt whenever a
+// contact has been written by anything else since it was loaded
+type contactIndex struct {
+	*models.ContactIndex
+	writes uint64
 }
 
 type FilterContactsResp struct {
@@ -27,44 +75,129 @@ type FilterContactsResp struct {
 	Results []models.Contact `json:"results" mapstructure:"results"`
 }
 
-func NewContactsController() *Contacts {
+func NewContactsController(dataStore store.Store, blobStore blobs.Store, jobRunner *jobs.Runner, clk clock.Clock, rng *random.Source) *Contacts {
 	return &Contacts{
-		CurrentContacts: config.Config.Contacts,
+		Blobs:  blobStore,
+		Clock:  clk,
+		Jobs:   jobRunner,
+		Random: rng,
+		Store:  dataStore,
 	}
 }
 
+// newJobID returns an unused ID for a background job, the import or export record it belongs to shares the same ID
+func (contControl *Contacts) newJobID() int {
+	return newRandomID(contControl.Random, 1, 1000000, func(id int) bool {
+		_, err := contControl.Jobs.Get(id)
+		return err != store.ErrRecordNotFound
+	})
+}
+
+// FailInterruptedJob marks the import or export record of a job interrupted by a server restart as failed
+func (contControl *Contacts) FailInterruptedJob(job models.Job) {
+	var err error
+	switch job.Action {
+	case RecordKindExport:
+		var contactExport models.ContactExport
+		if err = contControl.Store.GetRecord(RecordKindExport, job.ID, &contactExport); err == nil {
+			err = contControl.finishExport(contactExport, models.JobStatusFailed)
+		}
+	case RecordKindImport:
+		var contactImport models.ContactImport
+		if err = contControl.Store.GetRecord(RecordKindImport, job.ID, &contactImport); err == nil {
+			err = contControl.finishImport(contactImport, models.JobStatusFailed)
+		}
+	}
+	if err != nil {
+		log.WithFields(log.Fields{
+			"job.id":     job.ID,
+			"job.action": job.Action,
+			"error":      err.Error(),
+		}).Error("unable to mark the interrupted job's record failed")
+	}
+}
+
+// GetAll lists contacts, filtered by any of contactListParams. Like Freshdesk, only verified and unverified
+// contacts are listed unless the state filter asks for blocked or deleted ones.
 func (contControl *Contacts) GetAll(ctx *gin.Context) {
-	mustMatchEmail := false
-	mustMatchMobile := false
-	mustMatchPhone := false
+	query, fieldErrors := parseContactListQuery(ctx)
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
 
-	email := ctx.Query("email")
-	if len(email) > 0 {
-		mustMatchEmail = true
+	respContacts, err := contControl.Store.FindContacts(query)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
 	}
-	mobile := ctx.Query("mobile")
-	if len(mobile) > 0 {
-		mustMatchMobile = true
+	start, end := page.apply(ctx, len(respContacts))
+	ctx.JSON(http.StatusOK, respContacts[start:end])
+}
+
+// parseContactListQuery turns the contact list query params into a store query. The lookups of a single contact
+// (email, mobile, phone and unique_external_id) can't be combined with each other, but any of them can be combined
+// with company_id, state and updated_since.
+func parseContactListQuery(ctx *gin.Context) (store.ContactQuery, models.FieldErrors) {
+	query := store.ContactQuery{
+		Email:  ctx.Query("email"),
+		Mobile: ctx.Query("mobile"),
+		Phone:  ctx.Query("phone"),
+		States: []string{models.ContactStateUnverified, models.ContactStateVerified},
+	}
+	fieldErrors := checkQueryParams(ctx, contactListParams)
+
+	var lookups []string
+	for _, param := range []string{"email", "mobile", "phone", "unique_external_id"} {
+		if ctx.Query(param) != "" {
+			lookups = append(lookups, param)
+		}
+	}
+	if len(lookups) > 1 {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue(lookups[1], fmt.Sprintf("It can't be combined with %s, only one of email, mobile, phone and unique_external_id can be used", lookups[0])))
 	}
-	phone := ctx.Query("phone")
-	if len(phone) > 0 {
-		mustMatchPhone = true
+	if peopleID := ctx.Query("unique_external_id"); peopleID != "" {
+		query.PeopleIDs = []string{peopleID}
 	}
 
-	respContacts := []models.Contact{}
-	for _, cont := range contControl.CurrentContacts {
-		if mustMatchEmail && cont.Email != email {
-			continue
+	if value := ctx.Query("company_id"); value != "" {
+		companyID, err := strconv.Atoi(value)
+		if err != nil || companyID < 1 {
+			fieldErrors = append(fieldErrors, models.NewDatatypeMismatch("company_id", "String", "Positive Integer"))
+		}
+		query.CompanyID = companyID
+	}
+
+	if state := ctx.Query("state"); state != "" {
+		valid := false
+		for _, contactState := range models.ContactStates {
+			valid = valid || state == contactState
+		}
+		if !valid {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue("state", fmt.Sprintf("It should be one of these values: '%s'", strings.Join(models.ContactStates, ","))))
 		}
-		if mustMatchMobile && cont.Mobile != mobile {
+		query.States = []string{state}
+	}
+
+	// Freshdesk documents the parameter as _updated_since, but the ticket list's updated_since is accepted too
+	if ctx.Query("_updated_since") != "" && ctx.Query("updated_since") != "" {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("updated_since", "It can't be combined with _updated_since"))
+	}
+	for _, param := range []string{"_updated_since", "updated_since"} {
+		value := ctx.Query(param)
+		if value == "" {
 			continue
 		}
-		if mustMatchPhone && cont.Phone != phone {
+		since, err := models.ParseTime(value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue(param, "It should be in the 'YYYY-MM-DDTHH:MM:SSZ' format"))
 			continue
 		}
-		respContacts = append(respContacts, cont)
+		query.UpdatedSince = since.Time
 	}
-	ctx.JSON(http.StatusOK, respContacts)
+	return query, fieldErrors
 }
 
 func (contControl *Contacts) GetByID(ctx *gin.Context) {
@@ -73,122 +206,153 @@ func (contControl *Contacts) GetByID(ctx *gin.Context) {
 		return
 	}
 
-	contact, exists := contControl.CurrentContacts[intID]
-	if !exists {
+	contact, err := contControl.Store.GetContact(intID)
+	if err == store.ErrRecordNotFound {
 		ctx.JSON(http.StatusNotFound, nil)
-	} else {
-		ctx.JSON(http.StatusOK, contact)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
 	}
+	ctx.JSON(http.StatusOK, contact)
 }
 
 func (contControl *Contacts) Search(ctx *gin.Context) {
 
 }
 
+// Filter runs a filter query, such as "unique_external_id:'abc' AND updated_at:>'2023-01-01'", against the
+// contactSearchSchema fields. Like Freshdesk, results come 30 to a page for up to 10 pages.
 func (contControl *Contacts) Filter(ctx *gin.Context) {
 	queryStr := ctx.Query("query")
 	if len(queryStr) == 0 {
-		contControl.GetAll(ctx)
-	} else {
-		var matchingContacts []models.Contact
-		validValues := getValidValuesFromFilterQueryString(queryStr)
-		for _, cont := range contControl.CurrentContacts {
-			log.WithFields(log.Fields{
-				"contact.people_id": cont.PeopleID,
-			}).Debug("checking for matching people id")
-			if validValues[cont.PeopleID] {
-				log.Debug("match found")
-				matchingContacts = append(matchingContacts, cont)
-			}
-		}
-		resp := FilterContactsResp{
-			Total:   len(matchingContacts),
-			Results: matchingContacts,
+		// Without a query every contact is returned, unpaginated and whatever its state
+		allContacts, err := contControl.Store.ListContacts()
+		if err != nil {
+			respondStoreError(ctx, err)
+			return
 		}
-		ctx.JSON(http.StatusOK, resp)
+		ctx.JSON(http.StatusOK, allContacts)
+		return
 	}
-}
 
-// TODO: Make this more robust when required
-// Currently we expect to only filter by a specific field, with one or more statements separated by OR
-func getValidValuesFromFilterQueryString(query string) map[string]bool {
-	validValues := make(map[string]bool)
-	log.WithFields(log.Fields{
-		"query": query,
-	}).Debug("starting query string processing")
-	// Strip the surrounding double quotes form the query value\
-	query = strings.Trim(query, "\"")
-	// Divide up a *fieldName:fieldValue OR fieldName:fieldValue* query into separate statements
-	statements := strings.Split(query, " OR ")
-	for _, statement := range statements {
-		// Spit a *fieldName:fieldValue* statement into separate pieces
-		statementPieces := strings.Split(statement, ":")
-		// Add the fieldValue piece to the list of valid values, removing the single quotes if the value is in quotes
-		if len(statementPieces) > 1 {
-			trimmedValue := strings.Trim(statementPieces[1], "'")
-			if len(trimmedValue) > 0 {
-				validValues[trimmedValue] = true
-			}
+	page, fieldErrors := parseSearchPage(ctx)
+	query, err := search.Parse(contactSearchSchema, queryStr)
+	if err != nil {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("query", err.Error()))
+	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	allContacts, err := contControl.Store.ListContacts()
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	matchingContacts := []models.Contact{}
+	for _, contact := range allContacts {
+		// Like the contact list, a search leaves out deleted contacts
+		if !contact.Deleted && query.Matches(contact) {
+			matchingContacts = append(matchingContacts, contact)
 		}
 	}
 	log.WithFields(log.Fields{
-		"values.count": len(validValues),
-		"values":       validValues,
-	}).Debug("query string processed")
-	return validValues
+		"query":         queryStr,
+		"matches.count": len(matchingContacts),
+	}).Debug("contact search finished")
+
+	start, end := page.apply(ctx, len(matchingContacts))
+	ctx.JSON(http.StatusOK, FilterContactsResp{
+		Total:   len(matchingContacts),
+		Results: matchingContacts[start:end],
+	})
 }
 
 func (contControl *Contacts) Add(ctx *gin.Context) {
 	var newContact models.Contact
-	if err := ctx.BindJSON(&newContact); err != nil {
-		respMessage := ErrorResp{
-			Description: "unable to process new contact",
-			Errors: []ErrorDetails{
-				{
-					Field:   "",
-					Message: "bind failed",
-					Code:    "new_contact_failure",
-				},
-			},
-		}
-		ctx.JSON(http.StatusInternalServerError, respMessage)
+	_, fieldErrors, err := bindRequest(ctx, &newContact, contactReadOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
 		return
 	}
-
-	invalidFields, isValid, err := newContact.IsValid(contControl.CurrentContacts)
-	if !isValid {
-		var respErrorDetails []ErrorDetails
-		for _, field := range invalidFields {
-			fieldError := ErrorDetails{
-				Field:   field,
-				Message: err.Error(),
-				Code:    "invalid_field_value",
-			}
-			respErrorDetails = append(respErrorDetails, fieldError)
+	avatar, avatarErrors := readAvatar(ctx)
+	if len(avatarErrors) > 0 {
+		respondValidationFailed(ctx, append(fieldErrors, avatarErrors...))
+		return
+	}
+	if avatar != nil {
+		newContact.Avatar, err = contControl.storeAvatar(ctx, avatar)
+		if err != nil {
+			respondStoreError(ctx, err)
+			return
 		}
-		respMessage := ErrorResp{
-			Description: err.Error(),
-			Errors:      respErrorDetails,
+	}
+
+	newContact, fieldErrors, err = contControl.createContact(newContact, fieldErrors, &contactIndex{})
+	if err != nil || len(fieldErrors) > 0 {
+		if avatar != nil {
+			deleteBlob(contControl.Blobs, newContact.Avatar.Id)
 		}
-		ctx.JSON(http.StatusBadRequest, respMessage)
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
 		return
 	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+	ctx.JSON(http.StatusCreated, newContact)
+}
+
+// createContact validates the new contact against the existing ones and, if there are no decoding or validation
+// errors, stores it with a newly generated ID and timestamps. The index is loaded if needed and kept up to date, so
+// it can be passed to the next call.
+func (contControl *Contacts) createContact(newContact models.Contact, decodeErrors models.FieldErrors, index *contactIndex) (models.Contact, models.FieldErrors, error) {
+	contControl.mu.Lock()
+	defer contControl.mu.Unlock()
+
+	if err := contControl.loadIndex(index); err != nil {
+		return newContact, nil, err
+	}
+	validationErrors, _ := newContact.IsValid(index.ContactIndex)
+	fieldErrors := mergeFieldErrors(decodeErrors, validationErrors)
+	if len(fieldErrors) > 0 {
+		return newContact, fieldErrors, nil
+	}
 
 	// Generate a new, unique numeric ID for the contact and add it to the set of contacts
-	var newContactID int
-	for {
-		newContactID = 100000000000 + rand.Intn(900000000000)
-		if _, exists := contControl.CurrentContacts[newContactID]; !exists {
-			// Format the current UTC time in the Frontdesk compatible string of "YYYY-MM-DDTHH:MM:SSZ"
-			nowStr := time.Now().UTC().Format("2006-02-01T15:04:05Z")
-			newContact.ID = newContactID
-			newContact.CreatedAt = nowStr
-			newContact.UpdatedAt = nowStr
-			contControl.CurrentContacts[newContactID] = newContact
-			break
-		}
+	newContact.ID = newRandomID(contControl.Random, 100000000000, 900000000000, func(id int) bool {
+		_, err := contControl.Store.GetContact(id)
+		return err != store.ErrRecordNotFound
+	})
+	now := models.NewTime(contControl.Clock.Now())
+	newContact.CreatedAt = now
+	newContact.UpdatedAt = now
+	if err := contControl.Store.PutContact(newContact); err != nil {
+		return newContact, nil, err
+	}
+	contControl.writes++
+	index.Add(newContact)
+	index.writes = contControl.writes
+	return newContact, nil, nil
+}
+
+// loadIndex indexes the stored contacts, unless index is already up to date. mu must be held.
+func (contControl *Contacts) loadIndex(index *contactIndex) error {
+	if index.ContactIndex != nil && index.writes == contControl.writes {
+		return nil
 	}
-	ctx.JSON(http.StatusCreated, contControl.CurrentContacts[newContactID])
+	existingContacts, err := contControl.Store.ListContacts()
+	if err != nil {
+		return err
+	}
+	index.ContactIndex = models.NewContactIndex(existingContacts)
+	index.writes = contControl.writes
+	return nil
 }
 
 func (contControl *Contacts) Update(ctx *gin.Context) {
@@ -198,140 +362,166 @@ func (contControl *Contacts) Update(ctx *gin.Context) {
 	}
 
 	var updatedContact models.Contact
-	if err := ctx.BindJSON(&updatedContact); err != nil {
-		respMessage := ErrorResp{
-			Description: "unable to process new contact",
-			Errors: []ErrorDetails{
-				{
-					Field:   "",
-					Message: fmt.Sprintf("bind failed: %s", err.Error()),
-					Code:    "new_contact_failure",
-				},
-			},
-		}
-		ctx.JSON(http.StatusInternalServerError, respMessage)
+	present, fieldErrors, err := bindRequest(ctx, &updatedContact, contactReadOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
 		return
 	}
+	avatar, avatarErrors := readAvatar(ctx)
+	fieldErrors = append(fieldErrors, avatarErrors...)
 
-	contactUpdated := false
-	finalContact := contControl.CurrentContacts[intID]
+	contControl.mu.Lock()
+	defer contControl.mu.Unlock()
 
-	if updatedContact.Address != "" {
-		finalContact.Address = updatedContact.Address
-		contactUpdated = true
-	}
-	if updatedContact.Avatar != (models.ContactAvatar{}) {
-		finalContact.Avatar = updatedContact.Avatar
-		contactUpdated = true
-	}
-	if updatedContact.CompanyID != 0 {
-		finalContact.CompanyID = updatedContact.CompanyID
-		contactUpdated = true
-	}
-	if updatedContact.CustomFields != (models.ContactCustomFields{}) {
-		finalContact.CustomFields = updatedContact.CustomFields
-		contactUpdated = true
-	}
-	if updatedContact.Description != "" {
-		finalContact.Description = updatedContact.Description
-		contactUpdated = true
-	}
-	if updatedContact.Email != "" {
-		finalContact.Email = updatedContact.Email
-		contactUpdated = true
-	}
-	if updatedContact.JobTitle != "" {
-		finalContact.JobTitle = updatedContact.JobTitle
-		contactUpdated = true
+	// Only the fields present in the request are changed, an explicit null or "" clears the existing value
+	finalContact, err := contControl.Store.GetContact(intID)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
 	}
-	if updatedContact.Language != "" {
-		finalContact.Language = updatedContact.Language
-		contactUpdated = true
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
 	}
-	if updatedContact.Mobile != "" {
-		finalContact.Mobile = updatedContact.Mobile
-		contactUpdated = true
+	contactUpdated := applyPresentFields(&finalContact, &updatedContact, present)
+
+	existingContacts, err := contControl.Store.ListContacts()
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
 	}
-	if updatedContact.Name != "" {
-		finalContact.Name = updatedContact.Name
-		contactUpdated = true
+	validationErrors, _ := finalContact.IsValid(models.NewContactIndex(existingContacts))
+	fieldErrors = mergeFieldErrors(fieldErrors, validationErrors)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
 	}
-	if len(updatedContact.OtherCompanies) > 0 {
-		finalContact.OtherCompanies = updatedContact.OtherCompanies
+
+	// The new avatar is only stored once the rest of the update is known to be valid, the old one is replaced
+	oldAvatarID := 0
+	if avatar != nil {
+		oldAvatarID = finalContact.Avatar.Id
+		finalContact.Avatar, err = contControl.storeAvatar(ctx, avatar)
+		if err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
 		contactUpdated = true
 	}
-	if len(updatedContact.OtherEmails) > 0 {
-		finalContact.OtherEmails = updatedContact.OtherEmails
-		contactUpdated = true
+
+	if contactUpdated {
+		finalContact.UpdatedAt = models.NewTime(contControl.Clock.Now())
 	}
-	if updatedContact.PeopleID != "" {
-		finalContact.PeopleID = updatedContact.PeopleID
-		contactUpdated = true
+	if err := contControl.Store.PutContact(finalContact); err != nil {
+		if avatar != nil {
+			deleteBlob(contControl.Blobs, finalContact.Avatar.Id)
+		}
+		respondStoreError(ctx, err)
+		return
 	}
-	if updatedContact.Phone != "" {
-		finalContact.Phone = updatedContact.Phone
-		contactUpdated = true
+	contControl.writes++
+	if oldAvatarID != 0 {
+		deleteBlob(contControl.Blobs, oldAvatarID)
 	}
-	if len(updatedContact.Tags) > 0 {
-		finalContact.Tags = updatedContact.Tags
-		contactUpdated = true
+	ctx.JSON(http.StatusOK, finalContact)
+}
+
+// Delete soft deletes a contact the way Freshdesk does, it is kept with deleted set and only listed by the state
+// filter until it is restored or hard deleted
+func (contControl *Contacts) Delete(ctx *gin.Context) {
+	contControl.setDeleted(ctx, true)
+}
+
+// Restore undoes a soft delete
+func (contControl *Contacts) Restore(ctx *gin.Context) {
+	contControl.setDeleted(ctx, false)
+}
+
+func (contControl *Contacts) setDeleted(ctx *gin.Context, deleted bool) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
 	}
-	if updatedContact.TimeZone != "" {
-		finalContact.TimeZone = updatedContact.TimeZone
-		contactUpdated = true
+
+	contControl.mu.Lock()
+	defer contControl.mu.Unlock()
+
+	contact, err := contControl.Store.GetContact(intID)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
 	}
-	if updatedContact.TwitterID != "" {
-		finalContact.TwitterID = updatedContact.TwitterID
-		contactUpdated = true
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
 	}
-	if updatedContact.ViewAllTickets != nil {
-		finalContact.ViewAllTickets = updatedContact.ViewAllTickets
-		contactUpdated = true
+	if contact.Deleted != deleted {
+		contact.Deleted = deleted
+		contact.UpdatedAt = models.NewTime(contControl.Clock.Now())
+		if err := contControl.Store.PutContact(contact); err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
+		contControl.writes++
 	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
 
-	invalidFields, isValid, err := finalContact.IsValid(contControl.CurrentContacts)
-	if !isValid {
-		var respErrorDetails []ErrorDetails
-		for _, field := range invalidFields {
-			fieldError := ErrorDetails{
-				Field:   field,
-				Message: err.Error(),
-				Code:    "invalid_field_value",
-			}
-			respErrorDetails = append(respErrorDetails, fieldError)
-		}
-		respMessage := ErrorResp{
-			Description: err.Error(),
-			Errors:      respErrorDetails,
+// HardDelete removes a contact and its avatar for good. Like Freshdesk, the contact has to be soft deleted first
+// unless force=true is given.
+func (contControl *Contacts) HardDelete(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	fieldErrors := checkQueryParams(ctx, contactHardDeleteParams)
+	force := false
+	if value, ok := ctx.GetQuery("force"); ok {
+		if force, err = strconv.ParseBool(value); err != nil {
+			fieldErrors = append(fieldErrors, models.NewDatatypeMismatch("force", "String", "Boolean"))
 		}
-		ctx.JSON(http.StatusBadRequest, respMessage)
+	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
 		return
 	}
 
-	if contactUpdated {
-		// Format the current UTC time in the Frontdesk compatible string of "YYYY-MM-DDTHH:MM:SSZ"
-		nowStr := time.Now().UTC().Format("2006-02-01T15:04:05Z")
-		finalContact.UpdatedAt = nowStr
-	}
-	contControl.CurrentContacts[intID] = finalContact
-	ctx.JSON(http.StatusOK, contControl.CurrentContacts[intID])
-}
+	contControl.mu.Lock()
+	defer contControl.mu.Unlock()
 
-func (contControl *Contacts) Delete(ctx *gin.Context) {
-	intID, err := getIntID(ctx)
+	contact, err := contControl.Store.GetContact(intID)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
 	if err != nil {
+		respondStoreError(ctx, err)
 		return
 	}
-	delete(contControl.CurrentContacts, intID)
+	if !contact.Deleted && !force {
+		respondValidationFailed(ctx, models.FieldErrors{
+			models.NewInvalidValue("id", "The contact has to be deleted first, or force=true given"),
+		})
+		return
+	}
+	if err := contControl.Store.DeleteContact(intID); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	contControl.writes++
+	if contact.Avatar.Id != 0 {
+		deleteBlob(contControl.Blobs, contact.Avatar.Id)
+	}
 	ctx.JSON(http.StatusNoContent, nil)
 }
 
+// getIntID reads the id path param that every resource uses, responding with a 400 when it isn't a number
 func getIntID(ctx *gin.Context) (int, error) {
 	ID := ctx.Param(ParamNameContactID)
 	intID, err := strconv.Atoi(ID)
 	if err != nil {
 		respMessage := ErrorResp{
-			Description: "invalid contact id specified",
+			Description: "invalid id specified",
 			Errors: []ErrorDetails{
 				{
 					Field:   "id",
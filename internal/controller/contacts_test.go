@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/random"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+var testContact = models.Contact{
+	CustomFields: models.ContactCustomFields{Benefit: "CSNP"},
+	Email:        "ann@example.com",
+	ID:           1,
+	Name:         "Ann",
+	Phone:        "+15550001",
+}
+
+func newContactsRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	dataStore := store.NewMemory()
+	if err := dataStore.PutContact(testContact); err != nil {
+		t.Fatal(err)
+	}
+	contacts := NewContactsController(dataStore, nil, nil, clock.NewFrozen(time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)), random.New(1))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/contacts", contacts.Add)
+	router.DELETE(fmt.Sprintf("/contacts/:%s", ParamNameContactID), contacts.Delete)
+	router.DELETE(fmt.Sprintf("/contacts/:%s/hard_delete", ParamNameContactID), contacts.HardDelete)
+	router.GET("/contacts", contacts.GetAll)
+	router.GET(fmt.Sprintf("/contacts/:%s", ParamNameContactID), contacts.GetByID)
+	router.PUT(fmt.Sprintf("/contacts/:%s", ParamNameContactID), contacts.Update)
+	router.PUT(fmt.Sprintf("/contacts/:%s/restore", ParamNameContactID), contacts.Restore)
+	return router
+}
+
+func sendJSON(router *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", gin.MIMEJSON)
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestContactsAddValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantErrors []ErrorDetails
+	}{
+		{
+			name:       "invalid json",
+			body:       `{"name":`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []ErrorDetails{{Message: descInvalidJSON, Code: "invalid_json"}},
+		},
+		{
+			name:       "invalid field",
+			body:       `{"name":"Bob","email":"bob@example.com","created_at":"2023-01-01T00:00:00Z"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []ErrorDetails{{Field: "created_at", Message: "Unexpected/invalid field in request", Code: models.CodeInvalidField}},
+		},
+		{
+			name:       "datatype mismatch",
+			body:       `{"name":"Bob","email":"bob@example.com","active":"yes"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []ErrorDetails{{Field: "active", Message: "Value set is of type String.It should be a/an Boolean", Code: models.CodeDatatypeMismatch}},
+		},
+		{
+			name:       "missing field",
+			body:       `{"name":"Bob"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []ErrorDetails{
+				{Field: "email", Code: models.CodeMissingField},
+				{Field: "mobile", Code: models.CodeMissingField},
+				{Field: "phone", Code: models.CodeMissingField},
+				{Field: "twitter_id", Code: models.CodeMissingField},
+				{Field: "unique_external_id", Code: models.CodeMissingField},
+			},
+		},
+		{
+			name:       "invalid value",
+			body:       `{"name":"Bob","email":"bob"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []ErrorDetails{{Field: "email", Code: models.CodeInvalidValue}},
+		},
+		{
+			name:       "only duplicate values is a conflict",
+			body:       `{"name":"Bob","email":"ann@example.com"}`,
+			wantStatus: http.StatusConflict,
+			wantErrors: []ErrorDetails{{Field: "email", Code: models.CodeDuplicateValue}},
+		},
+		{
+			name:       "a duplicate value with other errors is a bad request",
+			body:       `{"email":"ann@example.com"}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []ErrorDetails{{Field: "email", Code: models.CodeDuplicateValue}, {Field: "name", Code: models.CodeMissingField}},
+		},
+		{
+			name:       "nested field",
+			body:       `{"name":"Bob","email":"bob@example.com","custom_fields":{"plan":"gold"}}`,
+			wantStatus: http.StatusBadRequest,
+			wantErrors: []ErrorDetails{{Field: "custom_fields.plan", Message: "Unexpected/invalid field in request", Code: models.CodeInvalidField}},
+		},
+		{
+			name:       "created",
+			body:       `{"name":"Bob","email":"bob@example.com"}`,
+			wantStatus: http.StatusCreated,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := sendJSON(newContactsRouter(t), http.MethodPost, "/contacts", test.body)
+			if recorder.Code != test.wantStatus {
+				t.Fatalf("POST /contacts status = %d, want %d: %s", recorder.Code, test.wantStatus, recorder.Body)
+			}
+			if test.wantErrors == nil {
+				return
+			}
+			var resp ErrorResp
+			if err := json.Unmarshal(recorder.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.Errors) != len(test.wantErrors) {
+				t.Fatalf("POST /contacts errors = %+v, want %+v", resp.Errors, test.wantErrors)
+			}
+			for i, want := range test.wantErrors {
+				got := resp.Errors[i]
+				if got.Field != want.Field || got.Code != want.Code || (want.Message != "" && got.Message != want.Message) {
+					t.Errorf("POST /contacts error %d = %+v, want %+v", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestContactsUpdatePresentFields(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		want        func(contact *models.Contact)
+		wantUpdated bool
+	}{
+		{
+			name: "absent fields are kept",
+			body: `{}`,
+			want: func(contact *models.Contact) {},
+		},
+		{
+			name:        "null clears a field",
+			body:        `{"phone":null}`,
+			want:        func(contact *models.Contact) { contact.Phone = "" },
+			wantUpdated: true,
+		},
+		{
+			name:        "empty string clears a field",
+			body:        `{"phone":""}`,
+			want:        func(contact *models.Contact) { contact.Phone = "" },
+			wantUpdated: true,
+		},
+		{
+			name:        "a custom field is changed on its own",
+			body:        `{"custom_fields":{"eligibility_status":"Yes"}}`,
+			want:        func(contact *models.Contact) { contact.CustomFields.EligibilityStatus = "Yes" },
+			wantUpdated: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := sendJSON(newContactsRouter(t), http.MethodPut, "/contacts/1", test.body)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("PUT /contacts/1 status = %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body)
+			}
+			var got models.Contact
+			if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+
+			want := testContact
+			test.want(&want)
+			if test.wantUpdated {
+				want.UpdatedAt = models.NewTime(time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC))
+			}
+			if !sameContactJSON(t, got, want) {
+				t.Errorf("PUT /contacts/1 = %s, want %+v", recorder.Body, want)
+			}
+		})
+	}
+}
+
+func TestContactsUpdateValidation(t *testing.T) {
+	recorder := sendJSON(newContactsRouter(t), http.MethodPut, "/contacts/1", `{"email":null,"phone":null}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("PUT /contacts/1 clearing every contact method status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+	recorder = sendJSON(newContactsRouter(t), http.MethodPut, "/contacts/abc", `{}`)
+	if recorder.Code != http.StatusBadRequest {
+		t.Errorf("PUT /contacts/abc status = %d, want %d", recorder.Code, http.StatusBadRequest)
+	}
+	recorder = sendJSON(newContactsRouter(t), http.MethodPut, "/contacts/2", `{}`)
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("PUT /contacts/2 status = %d, want %d", recorder.Code, http.StatusNotFound)
+	}
+}
+
+func TestContactsDelete(t *testing.T) {
+	router := newContactsRouter(t)
+	listed := func(query string) int {
+		var contacts []models.Contact
+		if err := json.Unmarshal(sendJSON(router, http.MethodGet, "/contacts"+query, "").Body.Bytes(), &contacts); err != nil {
+			t.Fatal(err)
+		}
+		return len(contacts)
+	}
+	steps := []struct {
+		method, path string
+		wantStatus   int
+		wantListed   int
+		wantDeleted  int
+	}{
+		{http.MethodDelete, "/contacts/1/hard_delete", http.StatusBadRequest, 1, 0},
+		{http.MethodDelete, "/contacts/1", http.StatusNoContent, 0, 1},
+		{http.MethodGet, "/contacts/1", http.StatusOK, 0, 1},
+		{http.MethodPut, "/contacts/1/restore", http.StatusNoContent, 1, 0},
+		{http.MethodDelete, "/contacts/1", http.StatusNoContent, 0, 1},
+		{http.MethodDelete, "/contacts/1/hard_delete", http.StatusNoContent, 0, 0},
+		{http.MethodGet, "/contacts/1", http.StatusNotFound, 0, 0},
+		{http.MethodDelete, "/contacts/1", http.StatusNotFound, 0, 0},
+	}
+	for _, step := range steps {
+		if recorder := sendJSON(router, step.method, step.path, ""); recorder.Code != step.wantStatus {
+			t.Fatalf("%s %s status = %d, want %d: %s", step.method, step.path, recorder.Code, step.wantStatus, recorder.Body)
+		}
+		if got := listed(""); got != step.wantListed {
+			t.Errorf("after %s %s, GET /contacts lists %d contacts, want %d", step.method, step.path, got, step.wantListed)
+		}
+		if got := listed("?state=deleted"); got != step.wantDeleted {
+			t.Errorf("after %s %s, GET /contacts?state=deleted lists %d contacts, want %d", step.method, step.path, got, step.wantDeleted)
+		}
+	}
+}
+
+func sameContactJSON(t *testing.T, a, b models.Contact) bool {
+	t.Helper()
+	aJSON, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bJSON, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(aJSON) == string(bJSON)
+}
@@ -1,5 +1,20 @@
 package controller
 
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/random"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
 type ErrorResp struct {
 	Description string         `json:"description"`
 	Errors      []ErrorDetails `json:"errors"`
@@ -10,3 +25,158 @@ type ErrorDetails struct {
 	Message string `json:"message"`
 	Code    string `json:"code"`
 }
+
+// respondStoreError sends a generic server error when the data store fails, the details are only logged
+func respondStoreError(ctx *gin.Context, err error) {
+	log.WithFields(log.Fields{
+		"request.method": ctx.Request.Method,
+		"request.path":   ctx.Request.URL.Path,
+		"error":          err.Error(),
+	}).Error("data store operation failed")
+	ctx.JSON(http.StatusInternalServerError, ErrorResp{
+		Description: "Unexpected error while processing the request",
+		Errors: []ErrorDetails{
+			{
+				Field:   "",
+				Message: "data store operation failed",
+				Code:    "internal_error",
+			},
+		},
+	})
+}
+
+// newRandomID returns a random ID in the range [min, min+span) that exists reports as not already in use
+func newRandomID(rng *random.Source, min, span int, exists func(int) bool) int {
+	for {
+		id := min + rng.Intn(span)
+		if !exists(id) {
+			return id
+		}
+	}
+}
+
+// decodeRecords returns every record of a kind in the record store
+func decodeRecords[T any](recordStore store.RecordStore, kind string) ([]T, error) {
+	encoded, err := recordStore.ListRecords(kind)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]T, len(encoded))
+	for i := range encoded {
+		if err := json.Unmarshal(encoded[i], &records[i]); err != nil {
+			return nil, err
+		}
+	}
+	return records, nil
+}
+
+// resourceURL returns the absolute URL of a sub-resource of the record with the given ID, under the path of the
+// current request, i.e. http://localhost:5000/api/v2/contacts/imports/ID/failures
+func resourceURL(ctx *gin.Context, id int, subResource string) string {
+	return fmt.Sprintf("%s%s/%d/%s", baseURL(ctx), strings.TrimSuffix(ctx.FullPath(), "/"), id, subResource)
+}
+
+// baseURL returns the scheme and host the current request was sent to, i.e. http://localhost:5000
+func baseURL(ctx *gin.Context) string {
+	scheme := "http"
+	if ctx.Request.TLS != nil {
+		scheme = "https"
+	}
+	return fmt.Sprintf("%s://%s", scheme, ctx.Request.Host)
+}
+
+const (
+	defaultPerPage = 30
+	maxPerPage     = 100
+)
+
+// pagination is the page and per_page query params of a Freshdesk list endpoint
+type pagination struct {
+	Page    int
+	PerPage int
+}
+
+// parsePagination reads the page and per_page query params, defaulting to the first page of 30 records
+func parsePagination(ctx *gin.Context) (pagination, models.FieldErrors) {
+	page := pagination{Page: 1, PerPage: defaultPerPage}
+	var fieldErrors models.FieldErrors
+	if value, ok := ctx.GetQuery("page"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			fieldErrors = append(fieldErrors, models.NewDatatypeMismatch("page", "String", "Positive Integer"))
+		}
+		page.Page = parsed
+	}
+	if value, ok := ctx.GetQuery("per_page"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPerPage {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue("per_page", fmt.Sprintf("It should be a Positive Integer less than or equal to %d", maxPerPage)))
+		}
+		page.PerPage = parsed
+	}
+	return page, fieldErrors
+}
+
+const (
+	searchPerPage  = 30
+	searchMaxPages = 10
+)
+
+// parseSearchPage reads the page query param of a search endpoint, which always returns 30 results per page and
+// no more than 10 pages
+func parseSearchPage(ctx *gin.Context) (pagination, models.FieldErrors) {
+	page := pagination{Page: 1, PerPage: searchPerPage}
+	if value, ok := ctx.GetQuery("page"); ok {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > searchMaxPages {
+			return page, models.FieldErrors{models.NewInvalidValue("page", fmt.Sprintf("It should be a Positive Integer less than or equal to %d", searchMaxPages))}
+		}
+		page.Page = parsed
+	}
+	return page, nil
+}
+
+// apply returns the bounds of the current page within total records, and sets the Link header Freshdesk uses to
+// point at the next page when there is one
+func (page pagination) apply(ctx *gin.Context, total int) (start, end int) {
+	// A page past the end is empty, this is checked before multiplying so a huge page number can't overflow
+	if page.Page-1 > total/page.PerPage {
+		return total, total
+	}
+	start = (page.Page - 1) * page.PerPage
+	end = start + page.PerPage
+	if end >= total {
+		return start, total
+	}
+
+	next := *ctx.Request.URL
+	query := next.Query()
+	query.Set("page", strconv.Itoa(page.Page+1))
+	query.Set("per_page", strconv.Itoa(page.PerPage))
+	next.RawQuery = query.Encode()
+	ctx.Header("Link", fmt.Sprintf("<%s%s>; rel=\"next\"", baseURL(ctx), next.RequestURI()))
+	return start, end
+}
+
+// checkQueryParams reports every query param that isn't in valid, the way Freshdesk rejects unknown filters
+func checkQueryParams(ctx *gin.Context, valid []string) (fieldErrors models.FieldErrors) {
+	allowed := map[string]bool{}
+	for _, param := range valid {
+		allowed[param] = true
+	}
+	params := make([]string, 0, len(ctx.Request.URL.Query()))
+	for param := range ctx.Request.URL.Query() {
+		params = append(params, param)
+	}
+	sort.Strings(params)
+	for _, param := range params {
+		if !allowed[param] {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   param,
+				Message: fmt.Sprintf("Unexpected/invalid field in request, valid query params are: %s", strings.Join(valid, ", ")),
+				Code:    models.CodeInvalidField,
+			})
+		}
+	}
+	return
+}
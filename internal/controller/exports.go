@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/SkyMack/staledesk/internal/jobs"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	RecordKindExport     = "contact_export"
+	RecordKindExportFile = "contact_export_file"
+)
+
+type exportReq struct {
+	Fields models.ContactExportFields `json:"fields"`
+}
+
+// Export starts a background export of every contact to CSV, with the requested default and custom field columns.
+// The export stays in_progress for the configured jobs.contact_export.duration, so clients have to poll for it.
+func (contControl *Contacts) Export(ctx *gin.Context) {
+	var req exportReq
+	_, fieldErrors, err := bindStrictJSON(ctx, &req, nil)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+	fieldErrors = append(fieldErrors, listInvalidExportFields(req.Fields)...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	contactExport := models.ContactExport{
+		ID:        contControl.newJobID(),
+		Status:    models.JobStatusInProgress,
+		CreatedAt: models.NewTime(contControl.Clock.Now()),
+		Fields:    req.Fields,
+	}
+	if err := contControl.Store.PutRecord(RecordKindExport, contactExport.ID, contactExport); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+
+	downloadURL := resourceURL(ctx, contactExport.ID, "download")
+	_, err = contControl.Jobs.Submit(contactExport.ID, jobs.Task{
+		Action: RecordKindExport,
+		Run: func(report jobs.ReportFunc) ([]models.JobItemResult, error) {
+			return nil, contControl.runExport(contactExport, downloadURL)
+		},
+		OnFailure: func(err error) {
+			contControl.finishExport(contactExport, models.JobStatusFailed)
+		},
+	})
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"id": contactExport.ID})
+}
+
+// GetExport returns the current state of an export, download_url is only set once it has completed
+func (contControl *Contacts) GetExport(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var contactExport models.ContactExport
+	err = contControl.Store.GetRecord(RecordKindExport, intID, &contactExport)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, contactExport)
+}
+
+// DownloadExport serves the CSV file of a completed export
+func (contControl *Contacts) DownloadExport(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var file string
+	err = contControl.Store.GetRecord(RecordKindExportFile, intID, &file)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"contacts_export_%d.csv\"", intID))
+	ctx.Data(http.StatusOK, "text/csv", []byte(file))
+}
+
+// runExport writes the CSV file and marks the export completed
+func (contControl *Contacts) runExport(contactExport models.ContactExport, downloadURL string) error {
+	file, err := contControl.exportCSV(contactExport.Fields)
+	if err != nil {
+		return err
+	}
+	if err := contControl.Store.PutRecord(RecordKindExportFile, contactExport.ID, file); err != nil {
+		return err
+	}
+	contactExport.DownloadURL = downloadURL
+	return contControl.finishExport(contactExport, models.JobStatusCompleted)
+}
+
+// finishExport saves the final state of an export
+func (contControl *Contacts) finishExport(contactExport models.ContactExport, status string) error {
+	contactExport.Status = status
+	completedAt := models.NewTime(contControl.Clock.Now())
+	contactExport.CompletedAt = &completedAt
+	err := contControl.Store.PutRecord(RecordKindExport, contactExport.ID, contactExport)
+	log.WithFields(log.Fields{
+		"export.id":     contactExport.ID,
+		"export.status": contactExport.Status,
+	}).Info("contact export finished")
+	return err
+}
+
+func (contControl *Contacts) exportCSV(fields models.ContactExportFields) (string, error) {
+	contacts, err := contControl.Store.ListContacts()
+	if err != nil {
+		return "", err
+	}
+
+	paths := append([]string{}, fields.DefaultFields...)
+	for _, customField := range fields.CustomFields {
+		paths = append(paths, "custom_fields."+customField)
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(append(append([]string{}, fields.DefaultFields...), fields.CustomFields...))
+	for _, contact := range contacts {
+		record := make([]string, 0, len(paths))
+		for _, path := range paths {
+			record = append(record, fieldToString(reflect.ValueOf(contact), path))
+		}
+		writer.Write(record)
+	}
+	writer.Flush()
+	return buf.String(), writer.Error()
+}
+
+func listInvalidExportFields(fields models.ContactExportFields) (fieldErrors models.FieldErrors) {
+	if len(fields.DefaultFields) == 0 && len(fields.CustomFields) == 0 {
+		return models.FieldErrors{models.NewMissingField("fields", "Please select at least one default_field or custom_field")}
+	}
+	contactType := reflect.TypeOf(models.Contact{})
+	for _, field := range fields.DefaultFields {
+		if strings.Contains(field, ".") || !isFieldPath(contactType, field) {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue("default_fields", fmt.Sprintf("%s is not a contact field", field)))
+		}
+	}
+	for _, field := range fields.CustomFields {
+		if strings.Contains(field, ".") || !isFieldPath(contactType, "custom_fields."+field) {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue("custom_fields", fmt.Sprintf("%s is not a contact custom field", field)))
+		}
+	}
+	return
+}
+
+// fieldToString formats the struct field at the dotted json path as a CSV cell, lists are comma separated
+func fieldToString(src reflect.Value, path string) string {
+	name, rest, nested := strings.Cut(path, ".")
+	field := src.Field(jsonFieldIndex(src.Type())[name])
+	if nested {
+		return fieldToString(field, rest)
+	}
+
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			return ""
+		}
+		field = field.Elem()
+	}
+	if !isNestedStruct(field.Type()) {
+		if stringer, ok := field.Interface().(fmt.Stringer); ok {
+			return stringer.String()
+		}
+	}
+	switch field.Kind() {
+	case reflect.Slice:
+		items := make([]string, 0, field.Len())
+		for i := 0; i < field.Len(); i++ {
+			items = append(items, fmt.Sprint(field.Index(i).Interface()))
+		}
+		return strings.Join(items, ",")
+	case reflect.Struct:
+		return ""
+	default:
+		return fmt.Sprint(field.Interface())
+	}
+}
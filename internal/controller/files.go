@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/SkyMack/staledesk/internal/blobs"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ParamNameFileID = "id"
+
+	// FilesPath is where uploaded files are served from, outside the API path like Freshdesk's attachment CDN
+	FilesPath = "/files"
+)
+
+var (
+	ErrUploadTooLarge = fmt.Errorf("uploaded file is too large")
+)
+
+type Files struct {
+	Blobs blobs.Store
+}
+
+func NewFilesController(blobStore blobs.Store) *Files {
+	return &Files{
+		Blobs: blobStore,
+	}
+}
+
+// GetByID serves the contents of an uploaded file with the content type it was uploaded with
+func (fileControl *Files) GetByID(ctx *gin.Context) {
+	intID, err := strconv.Atoi(ctx.Param(ParamNameFileID))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	blob, data, err := fileControl.Blobs.Get(intID)
+	if err == blobs.ErrBlobNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	defer data.Close()
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", blob.Name))
+	ctx.DataFromReader(http.StatusOK, int64(blob.Size), blob.ContentType, data, nil)
+}
+
+// fileURL returns the absolute URL an uploaded file is served from
+func fileURL(ctx *gin.Context, id int) string {
+	return fmt.Sprintf("%s%s/%d", baseURL(ctx), FilesPath, id)
+}
+
+// deleteBlob removes a blob that is no longer referenced, failures are only logged since the record that
+// referenced it has already been changed
+func deleteBlob(blobStore blobs.Store, id int) {
+	if err := blobStore.Delete(id); err != nil && err != blobs.ErrBlobNotFound {
+		log.WithFields(log.Fields{
+			"blob.id": id,
+			"error":   err.Error(),
+		}).Error("unable to delete blob")
+	}
+}
+
+// fileUpload is an uploaded file part read into memory, so it can be checked before anything is stored
+type fileUpload struct {
+	contentType string
+	data        []byte
+	name        string
+}
+
+// readUpload reads a file part of up to maxSize bytes, its content type is detected from the contents rather
+// than trusting the one the client sent
+func readUpload(header *multipart.FileHeader, maxSize int) (*fileUpload, error) {
+	if header.Size > int64(maxSize) {
+		return nil, ErrUploadTooLarge
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxSize {
+		return nil, ErrUploadTooLarge
+	}
+	return &fileUpload{
+		contentType: http.DetectContentType(data),
+		data:        data,
+		name:        header.Filename,
+	}, nil
+}
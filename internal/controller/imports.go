@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/SkyMack/staledesk/internal/jobs"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	FormNameImportFile     = "file"
+	FormNameImportMapping  = "contact_fields"
+	RecordKindImport       = "contact_import"
+	RecordKindImportErrors = "contact_import_failures"
+)
+
+var (
+	ErrImportColumnUnknown = fmt.Errorf("mapped column does not exist in the csv header")
+)
+
+// Import starts a background import of the uploaded CSV file.
+// The contact_fields[field] form values map a contact field to a CSV column, given either as a 0 based column index
+// or a header name. Without a mapping, columns whose header matches a contact field name are imported.
+func (contControl *Contacts) Import(ctx *gin.Context) {
+	fileHeader, err := ctx.FormFile(FormNameImportFile)
+	if err != nil {
+		respondValidationFailed(ctx, models.FieldErrors{
+			models.NewMissingField(FormNameImportFile, "It should be a/an CSV file"),
+		})
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	defer file.Close()
+
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil || len(rows) == 0 {
+		respondValidationFailed(ctx, models.FieldErrors{
+			models.NewInvalidValue(FormNameImportFile, "It should be a valid CSV file with a header row"),
+		})
+		return
+	}
+
+	mapping, fieldErrors := parseImportMapping(ctx.PostFormMap(FormNameImportMapping), rows[0])
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	contactImport := models.ContactImport{
+		ID:           contControl.newJobID(),
+		Status:       models.JobStatusInProgress,
+		CreatedAt:    models.NewTime(contControl.Clock.Now()),
+		TotalRecords: len(rows) - 1,
+	}
+	if err := contControl.Store.PutRecord(RecordKindImport, contactImport.ID, contactImport); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+
+	failuresURL := resourceURL(ctx, contactImport.ID, "failures")
+	_, err = contControl.Jobs.Submit(contactImport.ID, jobs.Task{
+		Action: RecordKindImport,
+		Run: func(report jobs.ReportFunc) ([]models.JobItemResult, error) {
+			return contControl.runImport(&contactImport, rows[1:], mapping, failuresURL, report)
+		},
+		// runImport keeps the counts of contactImport up to date, so a failed import records the rows it got through
+		OnFailure: func(err error) {
+			contControl.finishImport(contactImport, models.JobStatusFailed)
+		},
+	})
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusAccepted, gin.H{"id": contactImport.ID})
+}
+
+// GetImport returns the current state of an import
+func (contControl *Contacts) GetImport(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var contactImport models.ContactImport
+	err = contControl.Store.GetRecord(RecordKindImport, intID, &contactImport)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, contactImport)
+}
+
+// GetImportFailures downloads a CSV of every row that failed to import, the original values followed by the errors
+func (contControl *Contacts) GetImportFailures(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var failures []models.ContactImportFailure
+	err = contControl.Store.GetRecord(RecordKindImportErrors, intID, &failures)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for _, failure := range failures {
+		record := append([]string{strconv.Itoa(failure.Row)}, failure.Values...)
+		writer.Write(append(record, failure.Errors.Error()))
+	}
+	writer.Flush()
+
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"import_%d_failures.csv\"", intID))
+	ctx.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// runImport creates a contact for each row, recording every row that fails decoding or validation. The created
+// and failed counts of contactImport are kept up to date as it goes.
+func (contControl *Contacts) runImport(contactImport *models.ContactImport, rows [][]string, mapping map[string]int, failuresURL string, report jobs.ReportFunc) ([]models.JobItemResult, error) {
+	results := make([]models.JobItemResult, 0, len(rows))
+	failures := []models.ContactImportFailure{}
+	// The existing contacts are indexed once, and each created row is added, rather than rescanning them per row
+	index := &contactIndex{}
+	for idx, row := range rows {
+		newContact, fieldErrors := decodeImportRow(row, mapping)
+		newContact, fieldErrors, err := contControl.createContact(newContact, fieldErrors, index)
+		if err != nil {
+			return results, err
+		}
+		report(idx+1, len(rows))
+		if len(fieldErrors) > 0 {
+			failures = append(failures, models.ContactImportFailure{
+				// Row numbers match the line in the uploaded file, which starts with the header
+				Row:    idx + 2,
+				Values: row,
+				Errors: fieldErrors,
+			})
+			contactImport.FailedRecords++
+			results = append(results, models.JobItemResult{Success: false, Errors: fieldErrors})
+			continue
+		}
+		contactImport.CreatedRecords++
+		results = append(results, models.JobItemResult{ID: newContact.ID, Success: true})
+	}
+
+	if len(failures) > 0 {
+		contactImport.FailuresURL = failuresURL
+		if err := contControl.Store.PutRecord(RecordKindImportErrors, contactImport.ID, failures); err != nil {
+			return results, err
+		}
+	}
+	return results, contControl.finishImport(*contactImport, models.JobStatusCompleted)
+}
+
+// finishImport saves the final state of an import
+func (contControl *Contacts) finishImport(contactImport models.ContactImport, status string) error {
+	contactImport.Status = status
+	completedAt := models.NewTime(contControl.Clock.Now())
+	contactImport.CompletedAt = &completedAt
+	err := contControl.Store.PutRecord(RecordKindImport, contactImport.ID, contactImport)
+	log.WithFields(log.Fields{
+		"import.id":      contactImport.ID,
+		"import.created": contactImport.CreatedRecords,
+		"import.failed":  contactImport.FailedRecords,
+		"import.status":  contactImport.Status,
+	}).Info("contact import finished")
+	return err
+}
+
+// parseImportMapping resolves the contact_fields form values to column indexes
+func parseImportMapping(formMapping map[string]string, header []string) (map[string]int, models.FieldErrors) {
+	mapping := map[string]int{}
+	var fieldErrors models.FieldErrors
+
+	if len(formMapping) == 0 {
+		for idx, name := range header {
+			name = strings.TrimSpace(name)
+			if isFieldPath(reflect.TypeOf(models.Contact{}), name) {
+				mapping[name] = idx
+			}
+		}
+		return mapping, nil
+	}
+
+	for field, column := range formMapping {
+		if idx, err := strconv.Atoi(column); err == nil && idx >= 0 && idx < len(header) {
+			mapping[field] = idx
+			continue
+		}
+		found := false
+		for idx, name := range header {
+			if strings.TrimSpace(name) == column {
+				mapping[field] = idx
+				found = true
+				break
+			}
+		}
+		if !found {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue(
+				fmt.Sprintf("%s[%s]", FormNameImportMapping, field),
+				fmt.Sprintf("%s: %s", ErrImportColumnUnknown.Error(), column),
+			))
+		}
+	}
+	return mapping, fieldErrors
+}
+
+// decodeImportRow builds a contact from the mapped CSV cells. Nested fields are mapped with a dotted name,
+// i.e. "custom_fields.benefit", and list fields take a comma separated value.
+func decodeImportRow(row []string, mapping map[string]int) (models.Contact, models.FieldErrors) {
+	var (
+		newContact  models.Contact
+		fieldErrors models.FieldErrors
+	)
+	skip := map[string]bool{}
+	for _, field := range contactReadOnlyFields {
+		skip[field] = true
+	}
+
+	fields := make([]string, 0, len(mapping))
+	for field := range mapping {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		idx := mapping[field]
+		if idx >= len(row) || row[idx] == "" {
+			continue
+		}
+		if skip[field] {
+			fieldErrors = append(fieldErrors, models.NewInvalidField(field))
+			continue
+		}
+		if fieldError := setFieldFromString(reflect.ValueOf(&newContact).Elem(), field, row[idx]); fieldError != nil {
+			fieldErrors = append(fieldErrors, *fieldError)
+		}
+	}
+	return newContact, fieldErrors
+}
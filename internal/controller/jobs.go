@@ -0,0 +1,41 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/SkyMack/staledesk/internal/jobs"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ParamNameJobID = "id"
+)
+
+type Jobs struct {
+	Runner *jobs.Runner
+}
+
+func NewJobsController(jobRunner *jobs.Runner) *Jobs {
+	return &Jobs{
+		Runner: jobRunner,
+	}
+}
+
+// GetByID returns the status, progress, and per record results of any background job
+func (jobControl *Jobs) GetByID(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	job, err := jobControl.Runner.Get(intID)
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, job)
+}
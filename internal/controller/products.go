@@ -0,0 +1,418 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/random"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ParamNameEmailConfigID = "id"
+	ParamNameProductID     = "id"
+
+	RecordKindEmailConfig = "email_config"
+	RecordKindProduct     = "product"
+)
+
+var (
+	// productListParams are the query params accepted by the product and email config lists
+	productListParams = []string{"page", "per_page"}
+	// productReadOnlyFields are set by the server, for both products and email configs
+	productReadOnlyFields = []string{"created_at", "id", "updated_at"}
+)
+
+// Products serves the products and their email configs (support mailboxes)
+type Products struct {
+	Clock  clock.Clock
+	Random *random.Source
+	Store  store.RecordStore
+
+	// mu serializes the validate-then-write sequences, so the references between products and email configs
+	// stay valid
+	mu sync.Mutex
+}
+
+func NewProductsController(recordStore store.RecordStore, clk clock.Clock, rng *random.Source) *Products {
+	return &Products{
+		Clock:  clk,
+		Random: rng,
+		Store:  recordStore,
+	}
+}
+
+// newProductID returns an ID that isn't used by another record of the same kind
+func (prodControl *Products) newProductID(kind string) int {
+	return newRandomID(prodControl.Random, 1000000, 9000000, func(id int) bool {
+		var existing json.RawMessage
+		return prodControl.Store.GetRecord(kind, id, &existing) != store.ErrRecordNotFound
+	})
+}
+
+// orphanedProduct returns the ID of a product whose primary email stops being the reply address of one of its
+// email configs when the email configs change from before to after, or 0 if there isn't one
+func (prodControl *Products) orphanedProduct(before, after []models.EmailConfig) (int, error) {
+	products, err := decodeRecords[models.Product](prodControl.Store, RecordKindProduct)
+	if err != nil {
+		return 0, err
+	}
+	for _, product := range products {
+		if product.HasMailbox(before) && !product.HasMailbox(after) {
+			return product.ID, nil
+		}
+	}
+	return 0, nil
+}
+
+func (prodControl *Products) ListProducts(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, productListParams)
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+	products, err := decodeRecords[models.Product](prodControl.Store, RecordKindProduct)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	start, end := page.apply(ctx, len(products))
+	ctx.JSON(http.StatusOK, products[start:end])
+}
+
+func (prodControl *Products) GetProduct(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var product models.Product
+	if err := prodControl.Store.GetRecord(RecordKindProduct, intID, &product); err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	} else if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, product)
+}
+
+// AddProduct creates a product. It has no email configs yet, so it can't have a primary email either.
+func (prodControl *Products) AddProduct(ctx *gin.Context) {
+	var newProduct models.Product
+	_, fieldErrors, err := bindRequest(ctx, &newProduct, productReadOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+
+	prodControl.mu.Lock()
+	defer prodControl.mu.Unlock()
+
+	fieldErrors = mergeFieldErrors(fieldErrors, newProduct.IsValid())
+	if !newProduct.HasMailbox(nil) {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("primary_email", "It should be the reply_email of one of the product's email configs"))
+	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	newProduct.ID = prodControl.newProductID(RecordKindProduct)
+	now := models.NewTime(prodControl.Clock.Now())
+	newProduct.CreatedAt = now
+	newProduct.UpdatedAt = now
+	if err := prodControl.Store.PutRecord(RecordKindProduct, newProduct.ID, newProduct); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, newProduct)
+}
+
+func (prodControl *Products) UpdateProduct(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var update models.Product
+	present, fieldErrors, err := bindRequest(ctx, &update, productReadOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+
+	prodControl.mu.Lock()
+	defer prodControl.mu.Unlock()
+
+	var product models.Product
+	if err := prodControl.Store.GetRecord(RecordKindProduct, intID, &product); err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	} else if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	updated := applyPresentFields(&product, &update, present)
+
+	emailConfigs, err := decodeRecords[models.EmailConfig](prodControl.Store, RecordKindEmailConfig)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	fieldErrors = mergeFieldErrors(fieldErrors, product.IsValid())
+	if !product.HasMailbox(emailConfigs) {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("primary_email", "It should be the reply_email of one of the product's email configs"))
+	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	if updated {
+		product.UpdatedAt = models.NewTime(prodControl.Clock.Now())
+	}
+	if err := prodControl.Store.PutRecord(RecordKindProduct, product.ID, product); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, product)
+}
+
+// DeleteProduct removes a product, which can't have any email configs left
+func (prodControl *Products) DeleteProduct(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+
+	prodControl.mu.Lock()
+	defer prodControl.mu.Unlock()
+
+	var product models.Product
+	if err := prodControl.Store.GetRecord(RecordKindProduct, intID, &product); err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	} else if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	emailConfigs, err := decodeRecords[models.EmailConfig](prodControl.Store, RecordKindEmailConfig)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	for _, emailConfig := range emailConfigs {
+		if emailConfig.ProductID == intID {
+			respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("id", fmt.Sprintf("The product is still used by email config %d", emailConfig.ID))})
+			return
+		}
+	}
+	if err := prodControl.Store.DeleteRecord(RecordKindProduct, intID); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+func (prodControl *Products) ListEmailConfigs(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, productListParams)
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+	emailConfigs, err := decodeRecords[models.EmailConfig](prodControl.Store, RecordKindEmailConfig)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	start, end := page.apply(ctx, len(emailConfigs))
+	ctx.JSON(http.StatusOK, emailConfigs[start:end])
+}
+
+func (prodControl *Products) GetEmailConfig(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var emailConfig models.EmailConfig
+	if err := prodControl.Store.GetRecord(RecordKindEmailConfig, intID, &emailConfig); err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	} else if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, emailConfig)
+}
+
+func (prodControl *Products) AddEmailConfig(ctx *gin.Context) {
+	var newEmailConfig models.EmailConfig
+	_, fieldErrors, err := bindRequest(ctx, &newEmailConfig, productReadOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+
+	prodControl.mu.Lock()
+	defer prodControl.mu.Unlock()
+
+	emailConfigs, err := decodeRecords[models.EmailConfig](prodControl.Store, RecordKindEmailConfig)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	fieldErrors = mergeFieldErrors(fieldErrors, newEmailConfig.IsValid(emailConfigs))
+	productErrors, err := prodControl.checkProductID(newEmailConfig.ProductID)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	fieldErrors = append(fieldErrors, productErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	newEmailConfig.ID = prodControl.newProductID(RecordKindEmailConfig)
+	now := models.NewTime(prodControl.Clock.Now())
+	newEmailConfig.CreatedAt = now
+	newEmailConfig.UpdatedAt = now
+	if err := prodControl.Store.PutRecord(RecordKindEmailConfig, newEmailConfig.ID, newEmailConfig); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, newEmailConfig)
+}
+
+// UpdateEmailConfig changes an email config, as long as that leaves every product's primary email the reply
+// address of one of its email configs
+func (prodControl *Products) UpdateEmailConfig(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	var update models.EmailConfig
+	present, fieldErrors, err := bindRequest(ctx, &update, productReadOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+
+	prodControl.mu.Lock()
+	defer prodControl.mu.Unlock()
+
+	var emailConfig models.EmailConfig
+	if err := prodControl.Store.GetRecord(RecordKindEmailConfig, intID, &emailConfig); err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	} else if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	updated := applyPresentFields(&emailConfig, &update, present)
+
+	emailConfigs, err := decodeRecords[models.EmailConfig](prodControl.Store, RecordKindEmailConfig)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	fieldErrors = mergeFieldErrors(fieldErrors, emailConfig.IsValid(emailConfigs))
+	productErrors, err := prodControl.checkProductID(emailConfig.ProductID)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	fieldErrors = append(fieldErrors, productErrors...)
+
+	after := make([]models.EmailConfig, 0, len(emailConfigs))
+	for _, other := range emailConfigs {
+		if other.ID == emailConfig.ID {
+			other = emailConfig
+		}
+		after = append(after, other)
+	}
+	productID, err := prodControl.orphanedProduct(emailConfigs, after)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	if productID != 0 {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("reply_email", fmt.Sprintf("It is the primary_email of product %d, which has to be changed first", productID)))
+	}
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	if updated {
+		emailConfig.UpdatedAt = models.NewTime(prodControl.Clock.Now())
+	}
+	if err := prodControl.Store.PutRecord(RecordKindEmailConfig, emailConfig.ID, emailConfig); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, emailConfig)
+}
+
+// DeleteEmailConfig removes an email config, unless its reply address is still a product's primary email
+func (prodControl *Products) DeleteEmailConfig(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+
+	prodControl.mu.Lock()
+	defer prodControl.mu.Unlock()
+
+	emailConfigs, err := decodeRecords[models.EmailConfig](prodControl.Store, RecordKindEmailConfig)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	after := make([]models.EmailConfig, 0, len(emailConfigs))
+	for _, emailConfig := range emailConfigs {
+		if emailConfig.ID != intID {
+			after = append(after, emailConfig)
+		}
+	}
+	if len(after) == len(emailConfigs) {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	productID, err := prodControl.orphanedProduct(emailConfigs, after)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	if productID != 0 {
+		respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("id", fmt.Sprintf("Its reply_email is the primary_email of product %d, which has to be changed first", productID))})
+		return
+	}
+	if err := prodControl.Store.DeleteRecord(RecordKindEmailConfig, intID); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// checkProductID returns an error if an email config refers to a product that doesn't exist, 0 means the email
+// config belongs to the account rather than a product
+func (prodControl *Products) checkProductID(productID int) (models.FieldErrors, error) {
+	if productID == 0 {
+		return nil, nil
+	}
+	var product models.Product
+	err := prodControl.Store.GetRecord(RecordKindProduct, productID, &product)
+	if err == store.ErrRecordNotFound {
+		return models.FieldErrors{models.NewInvalidValue("product_id", "There is no product with this id")}, nil
+	}
+	return nil, err
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ParamNameBusinessHoursID = "id"
+)
+
+var (
+	// slaListParams are the query params accepted by the business hours and SLA policy lists
+	slaListParams = []string{"page", "per_page"}
+	// dueDateParams are the query params accepted by the due date calculation
+	dueDateParams = []string{"business_hours_id", "created_at", "priority", "sla_policy_id"}
+)
+
+// SLA serves the SLA policies and business hours calendars
+type SLA struct {
+	BusinessHours func() []models.BusinessHours
+	Clock         clock.Clock
+	Policies      func() []models.SLAPolicy
+}
+
+// DueDatesResp is when a ticket has to be first responded to and resolved by. BusinessHoursID is left out when
+// the target runs around the clock.
+type DueDatesResp struct {
+	BusinessHoursID int         `json:"business_hours_id,omitempty"`
+	CreatedAt       models.Time `json:"created_at"`
+	DueBy           models.Time `json:"due_by"`
+	FrDueBy         models.Time `json:"fr_due_by"`
+	Priority        int         `json:"priority"`
+	SLAPolicyID     int         `json:"sla_policy_id"`
+}
+
+func NewSLAController(businessHours func() []models.BusinessHours, policies func() []models.SLAPolicy, clk clock.Clock) *SLA {
+	return &SLA{
+		BusinessHours: businessHours,
+		Clock:         clk,
+		Policies:      policies,
+	}
+}
+
+func (slaControl *SLA) ListBusinessHours(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, slaListParams)
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+	calendars := append([]models.BusinessHours{}, slaControl.BusinessHours()...)
+	start, end := page.apply(ctx, len(calendars))
+	ctx.JSON(http.StatusOK, calendars[start:end])
+}
+
+func (slaControl *SLA) GetBusinessHours(ctx *gin.Context) {
+	intID, err := getIntID(ctx)
+	if err != nil {
+		return
+	}
+	for _, calendar := range slaControl.BusinessHours() {
+		if calendar.ID == intID {
+			ctx.JSON(http.StatusOK, calendar)
+			return
+		}
+	}
+	ctx.JSON(http.StatusNotFound, nil)
+}
+
+// ListPolicies returns the SLA policies in the order they are checked against a ticket
+func (slaControl *SLA) ListPolicies(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, slaListParams)
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+	policies := append([]models.SLAPolicy{}, slaControl.Policies()...)
+	start, end := page.apply(ctx, len(policies))
+	ctx.JSON(http.StatusOK, policies[start:end])
+}
+
+// GetDueDates returns the fr_due_by and due_by a ticket of the given priority gets, so SLA alerting can be tested
+// without tickets. The ticket is taken to be created now by the server clock, under the default SLA policy and
+// business hours calendar, unless created_at, sla_policy_id or business_hours_id say otherwise.
+func (slaControl *SLA) GetDueDates(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, dueDateParams)
+	createdAt := slaControl.Clock.Now()
+	if value := ctx.Query("created_at"); value != "" {
+		parsed, err := models.ParseTime(value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue("created_at", "It should be in the 'YYYY-MM-DDTHH:MM:SSZ' format"))
+		}
+		createdAt = parsed.Time
+	}
+	priority, err := strconv.Atoi(ctx.Query("priority"))
+	switch {
+	case ctx.Query("priority") == "":
+		fieldErrors = append(fieldErrors, models.NewMissingField("priority", "It should be one of these values: '1,2,3,4'"))
+	case err != nil || priority < 1 || priority > 4:
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("priority", "It should be one of these values: '1,2,3,4'"))
+	}
+	policyID, idErrors := optionalQueryID(ctx, "sla_policy_id")
+	fieldErrors = append(fieldErrors, idErrors...)
+	calendarID, idErrors := optionalQueryID(ctx, "business_hours_id")
+	fieldErrors = append(fieldErrors, idErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	var policy *models.SLAPolicy
+	for _, candidate := range slaControl.Policies() {
+		if candidate.ID == policyID || (policyID == 0 && candidate.IsDefault) {
+			policy = &candidate
+			break
+		}
+	}
+	if policy == nil {
+		respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("sla_policy_id", "There is no SLA policy with this id, or no default one")})
+		return
+	}
+	resp := DueDatesResp{
+		CreatedAt:   models.NewTime(createdAt),
+		Priority:    priority,
+		SLAPolicyID: policy.ID,
+	}
+
+	var calendar models.BusinessHours
+	if policy.SLATarget[models.SLATargetKey(priority)].BusinessHours {
+		found := false
+		for _, candidate := range slaControl.BusinessHours() {
+			if candidate.ID == calendarID || (calendarID == 0 && candidate.IsDefault) {
+				calendar, found = candidate, true
+				break
+			}
+		}
+		if !found {
+			respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("business_hours_id", "There is no business hours calendar with this id, or no default one")})
+			return
+		}
+		resp.BusinessHoursID = calendar.ID
+	}
+
+	frDueBy, dueBy, err := policy.DueDates(createdAt, priority, calendar)
+	switch {
+	case errors.Is(err, models.ErrNoSLATarget):
+		respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("priority", "The SLA policy has no target for this priority")})
+		return
+	case err != nil:
+		// The calendars are validated when the config is loaded, so only one that is never open gets here
+		respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue("business_hours_id", err.Error())})
+		return
+	}
+	resp.DueBy = models.NewTime(dueBy)
+	resp.FrDueBy = models.NewTime(frDueBy)
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// optionalQueryID reads an ID query param, 0 means it wasn't given
+func optionalQueryID(ctx *gin.Context, name string) (int, models.FieldErrors) {
+	value := ctx.Query(name)
+	if value == "" {
+		return 0, nil
+	}
+	id, err := strconv.Atoi(value)
+	if err != nil || id < 1 {
+		return 0, models.FieldErrors{models.NewInvalidValue(name, "It should be a/an Positive Integer")}
+	}
+	return id, nil
+}
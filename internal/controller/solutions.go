@@ -0,0 +1,768 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/random"
+	"github.com/SkyMack/staledesk/internal/store"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ParamNameLanguage   = "language"
+	ParamNameSolutionID = "id"
+
+	RecordKindSolutionArticle  = "solution_article"
+	RecordKindSolutionCategory = "solution_category"
+	RecordKindSolutionFolder   = "solution_folder"
+)
+
+var (
+	// articleListParams are the query params accepted by the article list
+	articleListParams = []string{"page", "per_page"}
+	// solutionSearchParams are the query params accepted by the article search
+	solutionSearchParams = []string{"page", "per_page", "term"}
+
+	solutionCategories = &solutionResource[models.SolutionCategory]{
+		deleteChildren: func(solControl *Solutions, id int) error {
+			return removeSolutionsUnder(solControl, solutionFolders, id)
+		},
+		id:                        func(category models.SolutionCategory) int { return category.ID },
+		kind:                      RecordKindSolutionCategory,
+		readOnlyFields:            []string{"created_at", "id", "updated_at"},
+		setCreatedAt:              func(category *models.SolutionCategory, now models.Time) { category.CreatedAt = now },
+		setID:                     func(category *models.SolutionCategory, id int) { category.ID = id },
+		stamp:                     func(category *models.SolutionCategory, now models.Time) { category.UpdatedAt = now },
+		translationReadOnlyFields: []string{"created_at", "id", "updated_at", "visible_in_portals"},
+		uniqueNames:               true,
+		validate:                  models.SolutionCategory.IsValid,
+		withTranslation:           models.SolutionCategory.WithTranslation,
+	}
+
+	solutionFolders = &solutionResource[models.SolutionFolder]{
+		deleteChildren: func(solControl *Solutions, id int) error {
+			return removeSolutionsUnder(solControl, solutionArticles, id)
+		},
+		id:                        func(folder models.SolutionFolder) int { return folder.ID },
+		kind:                      RecordKindSolutionFolder,
+		parentID:                  func(folder models.SolutionFolder) int { return folder.CategoryID },
+		parentKind:                RecordKindSolutionCategory,
+		readOnlyFields:            []string{"category_id", "created_at", "id", "updated_at"},
+		required:                  models.FieldErrors{models.NewMissingField("visibility", "It should be one of these values: '1,2,3,4'")},
+		setCreatedAt:              func(folder *models.SolutionFolder, now models.Time) { folder.CreatedAt = now },
+		setID:                     func(folder *models.SolutionFolder, id int) { folder.ID = id },
+		stamp:                     func(folder *models.SolutionFolder, now models.Time) { folder.UpdatedAt = now },
+		translationReadOnlyFields: []string{"category_id", "company_ids", "created_at", "id", "updated_at", "visibility"},
+		uniqueNames:               true,
+		validate:                  models.SolutionFolder.IsValid,
+		withTranslation:           models.SolutionFolder.WithTranslation,
+	}
+
+	solutionArticles = &solutionResource[models.SolutionArticle]{
+		id:             func(article models.SolutionArticle) int { return article.ID },
+		kind:           RecordKindSolutionArticle,
+		parentID:       func(article models.SolutionArticle) int { return article.FolderID },
+		parentKind:     RecordKindSolutionFolder,
+		readOnlyFields: []string{"category_id", "created_at", "description_text", "feedback_count", "folder_id", "hits", "id", "thumbs_down", "thumbs_up", "updated_at"},
+		required:       models.FieldErrors{models.NewMissingField("status", "It should be one of these values: '1,2'")},
+		setCreatedAt:   func(article *models.SolutionArticle, now models.Time) { article.CreatedAt = now },
+		setID:          func(article *models.SolutionArticle, id int) { article.ID = id },
+		stamp: func(article *models.SolutionArticle, now models.Time) {
+			article.DescriptionText = models.HTMLToText(article.Description)
+			if article.Tags == nil {
+				article.Tags = []string{}
+			}
+			article.UpdatedAt = now
+		},
+		translationReadOnlyFields: []string{"agent_id", "category_id", "created_at", "description_text", "feedback_count", "folder_id", "hits", "id", "thumbs_down", "thumbs_up", "type", "updated_at"},
+		validate: func(article models.SolutionArticle, _ []models.SolutionArticle) models.FieldErrors {
+			return article.IsValid()
+		},
+		withTranslation: models.SolutionArticle.WithTranslation,
+	}
+)
+
+// Solutions manages the knowledge base: categories hold folders, which hold articles. Each is stored in the
+// primary language, and each translation is stored as a record of its own under the same ID.
+type Solutions struct {
+	Clock  clock.Clock
+	Random *random.Source
+	Store  store.RecordStore
+
+	// mu serializes the validate-then-write sequences, so names stay unique and nothing is added to a parent
+	// that is being deleted
+	mu sync.Mutex
+}
+
+// solutionResource describes one kind of knowledge base record, so categories, folders and articles share the
+// same handlers. A resource only refers to its parent by kind, which keeps the resources from depending on each
+// other in both directions.
+type solutionResource[T any] struct {
+	// deleteChildren removes the records stored under a record that is being deleted
+	deleteChildren func(solControl *Solutions, id int) error
+	id             func(record T) int
+	kind           string
+	// parentID returns the ID of the record's parent, it is nil for the resources without one
+	parentID   func(record T) int
+	parentKind string
+	// The read only fields are set by the server, translations can only change the fields that are translated
+	readOnlyFields []string
+	// required holds the errors for the fields that have to be given when a record, or a translation of it, is
+	// created
+	required     models.FieldErrors
+	setCreatedAt func(record *T, now models.Time)
+	setID        func(record *T, id int)
+	// stamp fills in the fields derived from the others whenever the record or a translation is written
+	stamp                     func(record *T, now models.Time)
+	translationReadOnlyFields []string
+	// uniqueNames says whether validate needs the other records of the kind
+	uniqueNames     bool
+	validate        func(record T, existing []T) models.FieldErrors
+	withTranslation func(record, translation T) T
+}
+
+func NewSolutionsController(recordStore store.RecordStore, clk clock.Clock, rng *random.Source) *Solutions {
+	return &Solutions{
+		Clock:  clk,
+		Random: rng,
+		Store:  recordStore,
+	}
+}
+
+// translationKind returns the record kind a resource's translations into language are stored under
+func translationKind(kind, language string) string {
+	if language == models.SolutionsPrimaryLanguage {
+		return kind
+	}
+	return fmt.Sprintf("%s.%s", kind, language)
+}
+
+// solutionID reads the ID path param, responding with a 400 when it isn't a number
+func solutionID(ctx *gin.Context) (int, bool) {
+	intID, err := getIntID(ctx)
+	return intID, err == nil
+}
+
+// solutionLanguage reads the language path param. Any supported language can be read, but only the ones other than
+// the primary language can be written as translations.
+func solutionLanguage(ctx *gin.Context, write bool) (string, bool) {
+	language := ctx.Param(ParamNameLanguage)
+	if !models.IsSolutionLanguage(language) {
+		respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue(ParamNameLanguage, fmt.Sprintf("It should be one of these values: '%s'", strings.Join(models.SolutionLanguages, ",")))})
+		return "", false
+	}
+	if write && language == models.SolutionsPrimaryLanguage {
+		respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue(ParamNameLanguage, fmt.Sprintf("%s is the primary language, update the record itself instead", language))})
+		return "", false
+	}
+	return language, true
+}
+
+// solutionNoun returns what a kind of record is called in messages, i.e. folder for solution_folder
+func solutionNoun(kind string) string {
+	return strings.TrimPrefix(kind, "solution_")
+}
+
+// respondSolutionError responds with a 404 for a record that doesn't exist, and a 500 for any other error
+func respondSolutionError(ctx *gin.Context, err error) {
+	if err == store.ErrRecordNotFound {
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	respondStoreError(ctx, err)
+}
+
+// missingFields returns the required errors for the fields that weren't given, leaving out the read only ones
+func missingFields(required models.FieldErrors, present fieldPresence, readOnlyFields []string) models.FieldErrors {
+	var fieldErrors models.FieldErrors
+	for _, fieldError := range required {
+		readOnly := false
+		for _, field := range readOnlyFields {
+			readOnly = readOnly || field == fieldError.Field
+		}
+		if !present[fieldError.Field] && !readOnly {
+			fieldErrors = append(fieldErrors, fieldError)
+		}
+	}
+	return fieldErrors
+}
+
+// newSolutionID returns an ID that isn't used by another record of the same kind
+func (solControl *Solutions) newSolutionID(kind string) int {
+	return newRandomID(solControl.Random, 1000000, 9000000, func(id int) bool {
+		var existing json.RawMessage
+		return solControl.Store.GetRecord(kind, id, &existing) != store.ErrRecordNotFound
+	})
+}
+
+// solutionExists returns store.ErrRecordNotFound unless the record exists in the given language
+func (solControl *Solutions) solutionExists(kind string, id int, language string) error {
+	var existing json.RawMessage
+	if err := solControl.Store.GetRecord(kind, id, &existing); err != nil {
+		return err
+	}
+	return solControl.Store.GetRecord(translationKind(kind, language), id, &existing)
+}
+
+// deleteTranslations removes every translation of a record that is itself being deleted
+func (solControl *Solutions) deleteTranslations(kind string, id int) error {
+	for _, language := range models.SolutionLanguages {
+		if language == models.SolutionsPrimaryLanguage {
+			continue
+		}
+		if err := solControl.Store.DeleteRecord(translationKind(kind, language), id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadSolution returns a record in the given language
+func loadSolution[T any](solControl *Solutions, resource *solutionResource[T], id int, language string) (T, error) {
+	var record T
+	if err := solControl.Store.GetRecord(resource.kind, id, &record); err != nil {
+		return record, err
+	}
+	if language == models.SolutionsPrimaryLanguage {
+		return record, nil
+	}
+	var translation T
+	if err := solControl.Store.GetRecord(translationKind(resource.kind, language), id, &translation); err != nil {
+		return record, err
+	}
+	return resource.withTranslation(record, translation), nil
+}
+
+// loadSolutions returns the records under a parent, or every record when parentID is 0, in the given language. The
+// records without a translation into it are left out.
+func loadSolutions[T any](solControl *Solutions, resource *solutionResource[T], parentID int, language string) ([]T, error) {
+	records, err := decodeRecords[T](solControl.Store, resource.kind)
+	if err != nil {
+		return nil, err
+	}
+	var translations map[int]T
+	if language != models.SolutionsPrimaryLanguage {
+		translated, err := decodeRecords[T](solControl.Store, translationKind(resource.kind, language))
+		if err != nil {
+			return nil, err
+		}
+		translations = make(map[int]T, len(translated))
+		for _, translation := range translated {
+			translations[resource.id(translation)] = translation
+		}
+	}
+
+	matches := make([]T, 0, len(records))
+	for _, record := range records {
+		if parentID != 0 && resource.parentID(record) != parentID {
+			continue
+		}
+		if translations != nil {
+			translation, ok := translations[resource.id(record)]
+			if !ok {
+				continue
+			}
+			record = resource.withTranslation(record, translation)
+		}
+		matches = append(matches, record)
+	}
+	return matches, nil
+}
+
+// removeSolution deletes a record along with the records under it and every translation of them
+func removeSolution[T any](solControl *Solutions, resource *solutionResource[T], id int) error {
+	if resource.deleteChildren != nil {
+		if err := resource.deleteChildren(solControl, id); err != nil {
+			return err
+		}
+	}
+	if err := solControl.deleteTranslations(resource.kind, id); err != nil {
+		return err
+	}
+	return solControl.Store.DeleteRecord(resource.kind, id)
+}
+
+// removeSolutionsUnder deletes every record under a parent that is being deleted
+func removeSolutionsUnder[T any](solControl *Solutions, resource *solutionResource[T], parentID int) error {
+	records, err := loadSolutions(solControl, resource, parentID, models.SolutionsPrimaryLanguage)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := removeSolution(solControl, resource, resource.id(record)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getSolution responds with the record in the path, in the given language
+func getSolution[T any](ctx *gin.Context, solControl *Solutions, resource *solutionResource[T], language string) {
+	intID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+	record, err := loadSolution(solControl, resource, intID, language)
+	if err != nil {
+		respondSolutionError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, record)
+}
+
+// listSolutionsUnder returns the records under a parent in the given language, responding with a 404 when the
+// parent doesn't exist in it
+func listSolutionsUnder[T any](ctx *gin.Context, solControl *Solutions, resource *solutionResource[T], parentID int, language string) ([]T, bool) {
+	if err := solControl.solutionExists(resource.parentKind, parentID, language); err != nil {
+		respondSolutionError(ctx, err)
+		return nil, false
+	}
+	records, err := loadSolutions(solControl, resource, parentID, language)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return nil, false
+	}
+	return records, true
+}
+
+// addSolution creates a record from the request, starting from newRecord's defaults. attach is called once the
+// lock is held, to link the record to its parent.
+func addSolution[T any](ctx *gin.Context, solControl *Solutions, resource *solutionResource[T], newRecord T, attach func(record *T) error) {
+	present, fieldErrors, err := bindRequest(ctx, &newRecord, resource.readOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+	fieldErrors = append(fieldErrors, missingFields(resource.required, present, nil)...)
+
+	solControl.mu.Lock()
+	defer solControl.mu.Unlock()
+
+	if attach != nil {
+		if err := attach(&newRecord); err != nil {
+			respondSolutionError(ctx, err)
+			return
+		}
+	}
+	var existing []T
+	if resource.uniqueNames {
+		if existing, err = loadSolutions(solControl, resource, 0, models.SolutionsPrimaryLanguage); err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
+	}
+	fieldErrors = mergeFieldErrors(fieldErrors, resource.validate(newRecord, existing))
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	resource.setID(&newRecord, solControl.newSolutionID(resource.kind))
+	now := models.NewTime(solControl.Clock.Now())
+	resource.setCreatedAt(&newRecord, now)
+	resource.stamp(&newRecord, now)
+	if err := solControl.Store.PutRecord(resource.kind, resource.id(newRecord), newRecord); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, newRecord)
+}
+
+// updateSolution changes the fields given in the request of the record in the path. adjust, when set, can change
+// the update before it is applied.
+func updateSolution[T any](ctx *gin.Context, solControl *Solutions, resource *solutionResource[T], adjust func(update *T, present fieldPresence)) {
+	intID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+	var update T
+	present, fieldErrors, err := bindRequest(ctx, &update, resource.readOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+
+	solControl.mu.Lock()
+	defer solControl.mu.Unlock()
+
+	record, err := loadSolution(solControl, resource, intID, models.SolutionsPrimaryLanguage)
+	if err != nil {
+		respondSolutionError(ctx, err)
+		return
+	}
+	if adjust != nil {
+		adjust(&update, present)
+	}
+	updated := applyPresentFields(&record, &update, present)
+
+	var existing []T
+	if resource.uniqueNames {
+		if existing, err = loadSolutions(solControl, resource, 0, models.SolutionsPrimaryLanguage); err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
+	}
+	fieldErrors = mergeFieldErrors(fieldErrors, resource.validate(record, existing))
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	if updated {
+		resource.stamp(&record, models.NewTime(solControl.Clock.Now()))
+	}
+	if err := solControl.Store.PutRecord(resource.kind, intID, record); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, record)
+}
+
+// saveSolutionTranslation creates a record's translation into the language in the path, or updates the existing
+// one. Like Freshdesk, a record can only be translated once its parent has been.
+func saveSolutionTranslation[T any](ctx *gin.Context, solControl *Solutions, resource *solutionResource[T], create bool) {
+	intID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+	language, ok := solutionLanguage(ctx, true)
+	if !ok {
+		return
+	}
+	var update T
+	present, fieldErrors, err := bindRequest(ctx, &update, resource.translationReadOnlyFields)
+	if err != nil {
+		respondInvalidJSON(ctx)
+		return
+	}
+	if create {
+		fieldErrors = append(fieldErrors, missingFields(resource.required, present, resource.translationReadOnlyFields)...)
+	}
+
+	solControl.mu.Lock()
+	defer solControl.mu.Unlock()
+
+	record, err := loadSolution(solControl, resource, intID, models.SolutionsPrimaryLanguage)
+	if err != nil {
+		respondSolutionError(ctx, err)
+		return
+	}
+	if resource.parentID != nil {
+		err := solControl.solutionExists(resource.parentKind, resource.parentID(record), language)
+		if err == store.ErrRecordNotFound {
+			respondValidationFailed(ctx, models.FieldErrors{models.NewInvalidValue(ParamNameLanguage, fmt.Sprintf("The %s's %s has not been translated to %s", solutionNoun(resource.kind), solutionNoun(resource.parentKind), language))})
+			return
+		}
+		if err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
+	}
+	kind := translationKind(resource.kind, language)
+	var translation T
+	err = solControl.Store.GetRecord(kind, intID, &translation)
+	switch {
+	case err != nil && err != store.ErrRecordNotFound:
+		respondStoreError(ctx, err)
+		return
+	case err == nil && create:
+		respondValidationFailed(ctx, models.FieldErrors{models.NewDuplicateValue(ParamNameLanguage)})
+		return
+	case err == store.ErrRecordNotFound && !create:
+		ctx.JSON(http.StatusNotFound, nil)
+		return
+	}
+	updated := applyPresentFields(&translation, &update, present)
+
+	var existing []T
+	if resource.uniqueNames {
+		if existing, err = loadSolutions(solControl, resource, 0, language); err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
+	}
+	fieldErrors = mergeFieldErrors(fieldErrors, resource.validate(resource.withTranslation(record, translation), existing))
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	if create || updated {
+		resource.stamp(&translation, models.NewTime(solControl.Clock.Now()))
+	}
+	// The translation keeps the ID, so a list can match translations to records without reading them one by one
+	resource.setID(&translation, intID)
+	if err := solControl.Store.PutRecord(kind, intID, translation); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	status := http.StatusOK
+	if create {
+		status = http.StatusCreated
+	}
+	ctx.JSON(status, resource.withTranslation(record, translation))
+}
+
+// deleteSolution removes the record in the path along with the records under it and every translation of them
+func deleteSolution[T any](ctx *gin.Context, solControl *Solutions, resource *solutionResource[T]) {
+	intID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+
+	solControl.mu.Lock()
+	defer solControl.mu.Unlock()
+
+	if err := solControl.solutionExists(resource.kind, intID, models.SolutionsPrimaryLanguage); err != nil {
+		respondSolutionError(ctx, err)
+		return
+	}
+	if err := removeSolution(solControl, resource, intID); err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusNoContent, nil)
+}
+
+// ListCategories lists every category in the primary language
+func (solControl *Solutions) ListCategories(ctx *gin.Context) {
+	categories, err := loadSolutions(solControl, solutionCategories, 0, models.SolutionsPrimaryLanguage)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, categories)
+}
+
+// GetCategory returns a category. The same path lists the categories translated into a language when it is
+// given a language code instead of an ID, i.e. /solutions/categories/fr.
+func (solControl *Solutions) GetCategory(ctx *gin.Context) {
+	if language := ctx.Param(ParamNameSolutionID); models.IsSolutionLanguage(language) {
+		categories, err := loadSolutions(solControl, solutionCategories, 0, language)
+		if err != nil {
+			respondStoreError(ctx, err)
+			return
+		}
+		ctx.JSON(http.StatusOK, categories)
+		return
+	}
+	getSolution(ctx, solControl, solutionCategories, models.SolutionsPrimaryLanguage)
+}
+
+// GetCategoryTranslation returns a category translated into the language in the path
+func (solControl *Solutions) GetCategoryTranslation(ctx *gin.Context) {
+	if language, ok := solutionLanguage(ctx, false); ok {
+		getSolution(ctx, solControl, solutionCategories, language)
+	}
+}
+
+func (solControl *Solutions) AddCategory(ctx *gin.Context) {
+	addSolution(ctx, solControl, solutionCategories, models.SolutionCategory{}, nil)
+}
+
+func (solControl *Solutions) UpdateCategory(ctx *gin.Context) {
+	updateSolution(ctx, solControl, solutionCategories, nil)
+}
+
+func (solControl *Solutions) CreateCategoryTranslation(ctx *gin.Context) {
+	saveSolutionTranslation(ctx, solControl, solutionCategories, true)
+}
+
+func (solControl *Solutions) UpdateCategoryTranslation(ctx *gin.Context) {
+	saveSolutionTranslation(ctx, solControl, solutionCategories, false)
+}
+
+// DeleteCategory removes a category along with its folders, their articles and every translation of them
+func (solControl *Solutions) DeleteCategory(ctx *gin.Context) {
+	deleteSolution(ctx, solControl, solutionCategories)
+}
+
+// ListFolders lists the folders of the category in the path
+func (solControl *Solutions) ListFolders(ctx *gin.Context) {
+	solControl.listFoldersIn(ctx, models.SolutionsPrimaryLanguage)
+}
+
+// ListFolderTranslations lists the folders of the category in the path that are translated into its language
+func (solControl *Solutions) ListFolderTranslations(ctx *gin.Context) {
+	if language, ok := solutionLanguage(ctx, false); ok {
+		solControl.listFoldersIn(ctx, language)
+	}
+}
+
+func (solControl *Solutions) listFoldersIn(ctx *gin.Context, language string) {
+	intID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+	if folders, ok := listSolutionsUnder(ctx, solControl, solutionFolders, intID, language); ok {
+		ctx.JSON(http.StatusOK, folders)
+	}
+}
+
+// GetFolder returns a folder in the primary language
+func (solControl *Solutions) GetFolder(ctx *gin.Context) {
+	getSolution(ctx, solControl, solutionFolders, models.SolutionsPrimaryLanguage)
+}
+
+// GetFolderTranslation returns a folder translated into the language in the path
+func (solControl *Solutions) GetFolderTranslation(ctx *gin.Context) {
+	if language, ok := solutionLanguage(ctx, false); ok {
+		getSolution(ctx, solControl, solutionFolders, language)
+	}
+}
+
+// AddFolder creates a folder in the category in the path
+func (solControl *Solutions) AddFolder(ctx *gin.Context) {
+	categoryID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+	addSolution(ctx, solControl, solutionFolders, models.SolutionFolder{}, func(folder *models.SolutionFolder) error {
+		folder.CategoryID = categoryID
+		return solControl.solutionExists(RecordKindSolutionCategory, categoryID, models.SolutionsPrimaryLanguage)
+	})
+}
+
+func (solControl *Solutions) UpdateFolder(ctx *gin.Context) {
+	updateSolution(ctx, solControl, solutionFolders, func(update *models.SolutionFolder, present fieldPresence) {
+		// Moving away from selected companies drops the company list, rather than failing because it is still set
+		if present["visibility"] && !present["company_ids"] && update.Visibility != models.FolderVisibilitySelectedCompanies {
+			update.CompanyIDs = nil
+			present["company_ids"] = true
+		}
+	})
+}
+
+func (solControl *Solutions) CreateFolderTranslation(ctx *gin.Context) {
+	saveSolutionTranslation(ctx, solControl, solutionFolders, true)
+}
+
+func (solControl *Solutions) UpdateFolderTranslation(ctx *gin.Context) {
+	saveSolutionTranslation(ctx, solControl, solutionFolders, false)
+}
+
+// DeleteFolder removes a folder along with its articles and every translation of them
+func (solControl *Solutions) DeleteFolder(ctx *gin.Context) {
+	deleteSolution(ctx, solControl, solutionFolders)
+}
+
+// ListArticles lists the articles of the folder in the path, a page at a time
+func (solControl *Solutions) ListArticles(ctx *gin.Context) {
+	solControl.listArticlesIn(ctx, models.SolutionsPrimaryLanguage)
+}
+
+// ListArticleTranslations lists the articles of the folder in the path that are translated into its language
+func (solControl *Solutions) ListArticleTranslations(ctx *gin.Context) {
+	if language, ok := solutionLanguage(ctx, false); ok {
+		solControl.listArticlesIn(ctx, language)
+	}
+}
+
+func (solControl *Solutions) listArticlesIn(ctx *gin.Context, language string) {
+	intID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+	fieldErrors := checkQueryParams(ctx, articleListParams)
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	articles, ok := listSolutionsUnder(ctx, solControl, solutionArticles, intID, language)
+	if !ok {
+		return
+	}
+	start, end := page.apply(ctx, len(articles))
+	ctx.JSON(http.StatusOK, articles[start:end])
+}
+
+// GetArticle returns an article in the primary language
+func (solControl *Solutions) GetArticle(ctx *gin.Context) {
+	getSolution(ctx, solControl, solutionArticles, models.SolutionsPrimaryLanguage)
+}
+
+// GetArticleTranslation returns an article translated into the language in the path
+func (solControl *Solutions) GetArticleTranslation(ctx *gin.Context) {
+	if language, ok := solutionLanguage(ctx, false); ok {
+		getSolution(ctx, solControl, solutionArticles, language)
+	}
+}
+
+// AddArticle creates an article in the folder in the path. Articles are permanent unless a type is given.
+func (solControl *Solutions) AddArticle(ctx *gin.Context) {
+	folderID, ok := solutionID(ctx)
+	if !ok {
+		return
+	}
+	newArticle := models.SolutionArticle{Type: models.ArticleTypePermanent}
+	addSolution(ctx, solControl, solutionArticles, newArticle, func(article *models.SolutionArticle) error {
+		folder, err := loadSolution(solControl, solutionFolders, folderID, models.SolutionsPrimaryLanguage)
+		if err != nil {
+			return err
+		}
+		article.CategoryID = folder.CategoryID
+		article.FolderID = folder.ID
+		return nil
+	})
+}
+
+func (solControl *Solutions) UpdateArticle(ctx *gin.Context) {
+	updateSolution(ctx, solControl, solutionArticles, nil)
+}
+
+func (solControl *Solutions) CreateArticleTranslation(ctx *gin.Context) {
+	saveSolutionTranslation(ctx, solControl, solutionArticles, true)
+}
+
+func (solControl *Solutions) UpdateArticleTranslation(ctx *gin.Context) {
+	saveSolutionTranslation(ctx, solControl, solutionArticles, false)
+}
+
+// DeleteArticle removes an article and every translation of it
+func (solControl *Solutions) DeleteArticle(ctx *gin.Context) {
+	deleteSolution(ctx, solControl, solutionArticles)
+}
+
+// Search lists the articles whose title, text or tags contain every word of the term, drafts included. Only the
+// primary language is searched, translations are not.
+func (solControl *Solutions) Search(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, solutionSearchParams)
+	terms := strings.Fields(strings.ToLower(ctx.Query("term")))
+	if len(terms) == 0 {
+		fieldErrors = append(fieldErrors, models.NewMissingField("term", "It should be a/an String"))
+	}
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	articles, err := loadSolutions(solControl, solutionArticles, 0, models.SolutionsPrimaryLanguage)
+	if err != nil {
+		respondStoreError(ctx, err)
+		return
+	}
+	matches := []models.SolutionArticle{}
+	for _, article := range articles {
+		text := strings.ToLower(strings.Join(append([]string{article.Title, article.DescriptionText}, article.Tags...), " "))
+		matched := true
+		for _, term := range terms {
+			matched = matched && strings.Contains(text, term)
+		}
+		if matched {
+			matches = append(matches, article)
+		}
+	}
+	start, end := page.apply(ctx, len(matches))
+	ctx.JSON(http.StatusOK, matches[start:end])
+}
@@ -0,0 +1,90 @@
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultRatingsWindow is how far back the satisfaction ratings list goes when created_since isn't given
+	defaultRatingsWindow = 30 * 24 * time.Hour
+)
+
+var (
+	// surveyListParams are the query params accepted by the survey list
+	surveyListParams = []string{"page", "per_page", "state"}
+	// ratingListParams are the query params accepted by the satisfaction ratings list
+	ratingListParams = []string{"created_since", "page", "per_page"}
+)
+
+// Surveys serves the seeded surveys and satisfaction ratings
+type Surveys struct {
+	Clock   clock.Clock
+	Ratings func() []models.SatisfactionRating
+	Surveys func() []models.Survey
+}
+
+func NewSurveysController(surveys func() []models.Survey, ratings func() []models.SatisfactionRating, clk clock.Clock) *Surveys {
+	return &Surveys{
+		Clock:   clk,
+		Ratings: ratings,
+		Surveys: surveys,
+	}
+}
+
+// GetAll lists the surveys, or only the active one when state is active
+func (survControl *Surveys) GetAll(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, surveyListParams)
+	state := ctx.Query("state")
+	if state != "" && state != "active" {
+		fieldErrors = append(fieldErrors, models.NewInvalidValue("state", "It should be one of these values: 'active'"))
+	}
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	surveys := []models.Survey{}
+	for _, survey := range survControl.Surveys() {
+		if state == "" || survey.Active {
+			surveys = append(surveys, survey)
+		}
+	}
+	start, end := page.apply(ctx, len(surveys))
+	ctx.JSON(http.StatusOK, surveys[start:end])
+}
+
+// GetRatings lists the satisfaction ratings created since created_since. Like Freshdesk, only the last 30 days
+// of ratings are listed when it isn't given, so seeded ratings older than that need an earlier created_since.
+func (survControl *Surveys) GetRatings(ctx *gin.Context) {
+	fieldErrors := checkQueryParams(ctx, ratingListParams)
+	since := survControl.Clock.Now().Add(-defaultRatingsWindow)
+	if value := ctx.Query("created_since"); value != "" {
+		parsed, err := models.ParseTime(value)
+		if err != nil {
+			fieldErrors = append(fieldErrors, models.NewInvalidValue("created_since", "It should be in the 'YYYY-MM-DDTHH:MM:SSZ' format"))
+		}
+		since = parsed.Time
+	}
+	page, pageErrors := parsePagination(ctx)
+	fieldErrors = append(fieldErrors, pageErrors...)
+	if len(fieldErrors) > 0 {
+		respondValidationFailed(ctx, fieldErrors)
+		return
+	}
+
+	ratings := []models.SatisfactionRating{}
+	for _, rating := range survControl.Ratings() {
+		if !rating.CreatedAt.Before(since) {
+			ratings = append(ratings, rating)
+		}
+	}
+	start, end := page.apply(ctx, len(ratings))
+	ctx.JSON(http.StatusOK, ratings[start:end])
+}
@@ -0,0 +1,198 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/SkyMack/staledesk/internal/clock"
+	"github.com/SkyMack/staledesk/internal/models"
+	"github.com/SkyMack/staledesk/internal/random"
+	"github.com/SkyMack/staledesk/internal/store"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// RecordKindJob is the store record kind the generic job state is saved under
+	RecordKindJob = "job"
+
+	// progressSteps is how many progress updates are saved while waiting out a job's artificial duration
+	progressSteps = 10
+)
+
+var (
+	ErrSimulatedFailure = fmt.Errorf("job failed (simulated by the configured failure rate)")
+)
+
+// ReportFunc lets a task report how far through its work it is
+type ReportFunc func(done, total int)
+
+// Task is a unit of background work
+type Task struct {
+	// Action names the kind of job, it is used to look up its settings and returned in the job's action field
+	Action string
+	// Run does the work, the returned results are saved in the job's data field
+	Run func(report ReportFunc) ([]models.JobItemResult, error)
+	// OnFailure, if set, is called when the job fails, including simulated failures where Run is never called
+	OnFailure func(err error)
+}
+
+// Settings returns the artificial duration and failure rate for jobs with the given action
+type Settings func(action string) (duration time.Duration, failureRate float64)
+
+// Runner runs submitted tasks on a fixed size pool of workers, saving each job's state to the store as it goes
+type Runner struct {
+	Clock    clock.Clock
+	Random   *random.Source
+	Settings Settings
+	Store    store.RecordStore
+
+	queue chan queuedTask
+}
+
+type queuedTask struct {
+	job  models.Job
+	task Task
+}
+
+// NewRunner returns a Runner with the given number of workers already started. Job timestamps come from clk, but
+// the artificial durations are always waited out in real time.
+func NewRunner(recordStore store.RecordStore, workers int, settings Settings, clk clock.Clock, rng *random.Source) *Runner {
+	runner := &Runner{
+		Clock:    clk,
+		Random:   rng,
+		Settings: settings,
+		Store:    recordStore,
+		queue:    make(chan queuedTask),
+	}
+	for i := 0; i < workers; i++ {
+		go runner.work()
+	}
+	return runner
+}
+
+// Submit queues the task as the job with the given ID and returns the job in its queued state.
+// It never blocks, the task waits in the background until a worker is free.
+func (r *Runner) Submit(id int, task Task) (models.Job, error) {
+	now := models.NewTime(r.Clock.Now())
+	job := models.Job{
+		ID:        id,
+		Action:    task.Action,
+		Status:    models.JobStatusQueued,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := r.save(&job); err != nil {
+		return job, err
+	}
+	go func() {
+		r.queue <- queuedTask{job: job, task: task}
+	}()
+	return job, nil
+}
+
+// FailInterrupted marks every job that a previous run of the server left queued or in progress as failed, since
+// its task can't be resumed, then calls onFailure with it. It should be called before any job is submitted.
+func (r *Runner) FailInterrupted(onFailure func(job models.Job)) error {
+	records, err := r.Store.ListRecords(RecordKindJob)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		var job models.Job
+		if err := json.Unmarshal(record, &job); err != nil {
+			return err
+		}
+		if job.Status != models.JobStatusQueued && job.Status != models.JobStatusInProgress {
+			continue
+		}
+		job.Status = models.JobStatusFailed
+		if err := r.save(&job); err != nil {
+			return err
+		}
+		log.WithFields(log.Fields{
+			"job.id":     job.ID,
+			"job.action": job.Action,
+		}).Warn("job was interrupted by a server restart, marked it failed")
+		if onFailure != nil {
+			onFailure(job)
+		}
+	}
+	return nil
+}
+
+// Get returns the current state of a job
+func (r *Runner) Get(id int) (models.Job, error) {
+	var job models.Job
+	err := r.Store.GetRecord(RecordKindJob, id, &job)
+	return job, err
+}
+
+func (r *Runner) work() {
+	for queued := range r.queue {
+		r.run(queued.job, queued.task)
+	}
+}
+
+func (r *Runner) run(job models.Job, task Task) {
+	logLn := log.WithFields(log.Fields{
+		"job.id":     job.ID,
+		"job.action": job.Action,
+	})
+	duration, failureRate := r.Settings(task.Action)
+	logLn.WithFields(log.Fields{
+		"job.duration":     duration.String(),
+		"job.failure_rate": failureRate,
+	}).Info("job started")
+
+	job.Status = models.JobStatusInProgress
+	r.save(&job)
+
+	// Wait out the artificial duration, moving the progress along so polling clients see it change
+	for step := 1; step < progressSteps && duration > 0; step++ {
+		time.Sleep(duration / progressSteps)
+		job.Progress = step * 100 / progressSteps
+		r.save(&job)
+	}
+	if duration > 0 {
+		time.Sleep(duration / progressSteps)
+	}
+
+	var err error
+	if failureRate > 0 && r.Random.Float64() < failureRate {
+		err = ErrSimulatedFailure
+	} else {
+		job.Data, err = task.Run(func(done, total int) {
+			if total <= 0 || duration > 0 {
+				return
+			}
+			job.Progress = done * 100 / total
+			r.save(&job)
+		})
+	}
+
+	if err != nil {
+		logLn.WithField("error", err.Error()).Error("job failed")
+		job.Status = models.JobStatusFailed
+		if task.OnFailure != nil {
+			task.OnFailure(err)
+		}
+	} else {
+		job.Status = models.JobStatusCompleted
+		job.Progress = 100
+	}
+	r.save(&job)
+	logLn.WithField("job.status", job.Status).Info("job finished")
+}
+
+func (r *Runner) save(job *models.Job) error {
+	job.UpdatedAt = models.NewTime(r.Clock.Now())
+	err := r.Store.PutRecord(RecordKindJob, job.ID, job)
+	if err != nil {
+		log.WithFields(log.Fields{
+			"job.id": job.ID,
+			"error":  err.Error(),
+		}).Error("unable to save job state")
+	}
+	return err
+}
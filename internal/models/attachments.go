@@ -0,0 +1,12 @@
+package models
+
+// Attachment is a file attached to a record, i.e. a canned response, in the form Freshdesk lists them
+type Attachment struct {
+	AttachmentURL string `json:"attachment_url" mapstructure:"attachment_url"`
+	ContentType   string `json:"content_type" mapstructure:"content_type"`
+	CreatedAt     Time   `json:"created_at" mapstructure:"created_at"`
+	ID            int    `json:"id" mapstructure:"id"`
+	Name          string `json:"name" mapstructure:"name"`
+	Size          int    `json:"size" mapstructure:"size"`
+	UpdatedAt     Time   `json:"updated_at" mapstructure:"updated_at"`
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// BusinessHoursTimeLayout is how Freshdesk writes the opening and closing times of a working day
+	BusinessHoursTimeLayout = "3:04 pm"
+	// HolidayDateLayout is how Freshdesk writes a holiday, which falls on the same date every year
+	HolidayDateLayout = "Jan 02"
+
+	// maxBusinessDaysSearched stops AddBusinessTime looping forever on a calendar that is never open
+	maxBusinessDaysSearched = 3 * 366
+)
+
+var (
+	ErrBusinessHoursInvalid = fmt.Errorf("business hours calendar is invalid")
+	ErrNeverOpen            = fmt.Errorf("business hours calendar is never open")
+
+	weekdays = map[string]time.Weekday{
+		"sunday":    time.Sunday,
+		"monday":    time.Monday,
+		"tuesday":   time.Tuesday,
+		"wednesday": time.Wednesday,
+		"thursday":  time.Thursday,
+		"friday":    time.Friday,
+		"saturday":  time.Saturday,
+	}
+)
+
+// BusinessHours is a Freshdesk business hours calendar. A calendar without any working days listed is open
+// around the clock, apart from its holidays.
+type BusinessHours struct {
+	BusinessHours  map[string]BusinessHoursDay `json:"business_hours" mapstructure:"business_hours"`
+	CreatedAt      Time                        `json:"created_at" mapstructure:"created_at"`
+	Description    string                      `json:"description" mapstructure:"description"`
+	ID             int                         `json:"id" mapstructure:"id"`
+	IsDefault      bool                        `json:"is_default" mapstructure:"is_default"`
+	ListOfHolidays []Holiday                   `json:"list_of_holidays" mapstructure:"list_of_holidays"`
+	Name           string                      `json:"name" mapstructure:"name"`
+	TimeZone       string                      `json:"time_zone" mapstructure:"time_zone"`
+	UpdatedAt      Time                        `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// BusinessHoursDay is the opening and closing time of a working day, i.e. "8:00 am" and "5:00 pm"
+type BusinessHoursDay struct {
+	EndTime   string `json:"end_time" mapstructure:"end_time"`
+	StartTime string `json:"start_time" mapstructure:"start_time"`
+}
+
+// Holiday is a day, such as "Jan 01", that the calendar is closed every year
+type Holiday struct {
+	HolidayDate string `json:"holiday_date" mapstructure:"holiday_date"`
+	HolidayName string `json:"holiday_name" mapstructure:"holiday_name"`
+}
+
+// workingDay is a BusinessHoursDay parsed into offsets from midnight
+type workingDay struct {
+	open, close time.Duration
+}
+
+// Validate checks the time zone, working hours and holidays can all be read
+func (bh BusinessHours) Validate() error {
+	_, _, _, err := bh.schedule()
+	return err
+}
+
+// schedule parses the calendar into its location, working days by weekday and holidays by month and day
+func (bh BusinessHours) schedule() (*time.Location, map[time.Weekday]workingDay, map[string]bool, error) {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: business hours %d: %s", ErrBusinessHoursInvalid, bh.ID, fmt.Sprintf(format, args...))
+	}
+
+	location, err := LoadTimeZone(bh.TimeZone)
+	if err != nil {
+		return nil, nil, nil, invalid("%s", err.Error())
+	}
+
+	days := map[time.Weekday]workingDay{}
+	for name, hours := range bh.BusinessHours {
+		weekday, ok := weekdays[strings.ToLower(name)]
+		if !ok {
+			return nil, nil, nil, invalid("%q is not a day of the week", name)
+		}
+		open, err := parseTimeOfDay(hours.StartTime)
+		if err != nil {
+			return nil, nil, nil, invalid("%s start_time %q should be like \"8:00 am\"", name, hours.StartTime)
+		}
+		close, err := parseTimeOfDay(hours.EndTime)
+		if err != nil {
+			return nil, nil, nil, invalid("%s end_time %q should be like \"5:00 pm\"", name, hours.EndTime)
+		}
+		// Freshdesk writes a day that runs until midnight as closing at 11:59 pm
+		if close == 23*time.Hour+59*time.Minute {
+			close = 24 * time.Hour
+		}
+		if close <= open {
+			return nil, nil, nil, invalid("%s end_time is not after its start_time", name)
+		}
+		days[weekday] = workingDay{open: open, close: close}
+	}
+
+	holidays := map[string]bool{}
+	for _, holiday := range bh.ListOfHolidays {
+		date, err := time.Parse(HolidayDateLayout, holiday.HolidayDate)
+		if err != nil {
+			return nil, nil, nil, invalid("holiday_date %q should be like \"Jan 01\"", holiday.HolidayDate)
+		}
+		holidays[date.Format(HolidayDateLayout)] = true
+	}
+	return location, days, holidays, nil
+}
+
+// AddBusinessTime returns the time d of working time after start, skipping nights, days off and holidays in the
+// calendar's time zone. The result is in UTC.
+func (bh BusinessHours) AddBusinessTime(start time.Time, d time.Duration) (time.Time, error) {
+	location, days, holidays, err := bh.schedule()
+	if err != nil {
+		return time.Time{}, err
+	}
+	alwaysOpen := len(days) == 0
+
+	current := start.In(location)
+	remaining := d
+	for searched := 0; searched < maxBusinessDaysSearched; searched++ {
+		year, month, day := current.Date()
+		nextMidnight := time.Date(year, month, day+1, 0, 0, 0, 0, location)
+
+		hours, working := days[current.Weekday()]
+		if alwaysOpen {
+			hours, working = workingDay{open: 0, close: 24 * time.Hour}, true
+		}
+		if !working || holidays[current.Format(HolidayDateLayout)] {
+			current = nextMidnight
+			continue
+		}
+
+		// Opening times are wall clock times, so they are set on the date rather than added to midnight, which
+		// would be off by an hour on the days daylight saving time starts or ends
+		open := atTimeOfDay(current, hours.open)
+		close := nextMidnight
+		if hours.close < 24*time.Hour {
+			close = atTimeOfDay(current, hours.close)
+		}
+		if current.Before(open) {
+			current = open
+		}
+		if !current.Before(close) {
+			current = nextMidnight
+			continue
+		}
+		available := close.Sub(current)
+		if remaining <= available {
+			return current.Add(remaining).UTC(), nil
+		}
+		remaining -= available
+		current = nextMidnight
+	}
+	return time.Time{}, fmt.Errorf("%w: business hours %d", ErrNeverOpen, bh.ID)
+}
+
+// atTimeOfDay returns the wall clock time offset after midnight on the same date as t
+func atTimeOfDay(t time.Time, offset time.Duration) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, t.Location())
+}
+
+func parseTimeOfDay(value string) (time.Duration, error) {
+	parsed, err := time.Parse(BusinessHoursTimeLayout, strings.ToLower(strings.TrimSpace(value)))
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+	// The tests shouldn't depend on the time zone database of the machine running them
+	_ "time/tzdata"
+)
+
+func weekdayHours(open, close string) map[string]BusinessHoursDay {
+	hours := map[string]BusinessHoursDay{}
+	for _, day := range []string{"monday", "tuesday", "wednesday", "thursday", "friday"} {
+		hours[day] = BusinessHoursDay{StartTime: open, EndTime: close}
+	}
+	return hours
+}
+
+// testCalendar matches the default calendar in config/conf.json
+var testCalendar = BusinessHours{
+	BusinessHours: weekdayHours("8:00 am", "5:00 pm"),
+	ID:            1,
+	ListOfHolidays: []Holiday{
+		{HolidayDate: "Jan 01", HolidayName: "New Year's Day"},
+		{HolidayDate: "Dec 25", HolidayName: "Christmas Day"},
+	},
+	TimeZone: "Eastern Time (US & Canada)",
+}
+
+func mustParseUTC(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestAddBusinessTime(t *testing.T) {
+	lateNights := BusinessHours{
+		BusinessHours: map[string]BusinessHoursDay{"monday": {StartTime: "9:00 am", EndTime: "11:59 pm"}},
+		TimeZone:      "UTC",
+	}
+	alwaysOpen := BusinessHours{
+		ListOfHolidays: []Holiday{{HolidayDate: "Dec 25"}},
+		TimeZone:       "UTC",
+	}
+
+	tests := []struct {
+		name     string
+		calendar BusinessHours
+		start    string
+		duration time.Duration
+		want     string
+	}{
+		{"within the working day", testCalendar, "2023-02-13T14:00:00Z", 2 * time.Hour, "2023-02-13T16:00:00Z"},
+		{"before opening", testCalendar, "2023-02-13T10:00:00Z", time.Hour, "2023-02-13T14:00:00Z"},
+		{"at closing", testCalendar, "2023-02-13T22:00:00Z", time.Hour, "2023-02-14T14:00:00Z"},
+		{"over the weekend", testCalendar, "2023-02-10T21:30:00Z", time.Hour, "2023-02-13T13:30:00Z"},
+		{"zero during the weekend", testCalendar, "2023-02-11T15:00:00Z", 0, "2023-02-13T13:00:00Z"},
+		{"several days", testCalendar, "2023-02-10T21:30:00Z", 48 * time.Hour, "2023-02-20T15:30:00Z"},
+		{"skips a holiday", testCalendar, "2023-12-22T21:00:00Z", 4 * time.Hour, "2023-12-26T16:00:00Z"},
+		{"daylight saving time starts", testCalendar, "2023-03-10T21:00:00Z", 8 * time.Hour, "2023-03-13T19:00:00Z"},
+		{"daylight saving time ends", testCalendar, "2023-11-03T20:00:00Z", 2 * time.Hour, "2023-11-06T14:00:00Z"},
+		{"11:59 pm closes at midnight", lateNights, "2023-02-13T23:00:00Z", 2 * time.Hour, "2023-02-20T10:00:00Z"},
+		{"always open apart from holidays", alwaysOpen, "2023-12-24T12:00:00Z", 24 * time.Hour, "2023-12-26T12:00:00Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.calendar.AddBusinessTime(mustParseUTC(t, test.start), test.duration)
+			if err != nil {
+				t.Fatalf("AddBusinessTime() error = %v", err)
+			}
+			if want := mustParseUTC(t, test.want); !got.Equal(want) || got.Location() != time.UTC {
+				t.Errorf("AddBusinessTime() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestBusinessHoursValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		calendar BusinessHours
+	}{
+		{"unknown time zone", BusinessHours{TimeZone: "Mars/Olympus Mons"}},
+		{"unknown day", BusinessHours{BusinessHours: map[string]BusinessHoursDay{"funday": {StartTime: "8:00 am", EndTime: "5:00 pm"}}}},
+		{"24 hour start time", BusinessHours{BusinessHours: weekdayHours("08:00", "5:00 pm")}},
+		{"closes before opening", BusinessHours{BusinessHours: weekdayHours("5:00 pm", "8:00 am")}},
+		{"holiday with a year", BusinessHours{ListOfHolidays: []Holiday{{HolidayDate: "2023-12-25"}}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.calendar.Validate(); !errors.Is(err, ErrBusinessHoursInvalid) {
+				t.Errorf("Validate() error = %v, want ErrBusinessHoursInvalid", err)
+			}
+		})
+	}
+
+	if err := testCalendar.Validate(); err != nil {
+		t.Errorf("Validate() of the default calendar error = %v", err)
+	}
+}
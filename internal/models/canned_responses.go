@@ -0,0 +1,68 @@
+package models
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// placeholderPattern matches a Liquid placeholder such as {{ticket.requester.name}}, with an optional default
+	// filter as in {{ticket.requester.firstname | default: "there"}}
+	placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z0-9_.]+)\s*(?:\|\s*default:\s*(?:"([^"]*)"|'([^']*)')\s*)?\}\}`)
+)
+
+// CannedResponseFolder groups canned responses. ResponsesCount and CannedResponses are filled in when the folder
+// is served, rather than read from the config.
+type CannedResponseFolder struct {
+	CannedResponses []CannedResponse `json:"canned_responses,omitempty" mapstructure:"-"`
+	CreatedAt       Time             `json:"created_at" mapstructure:"created_at"`
+	ID              int              `json:"id" mapstructure:"id"`
+	Name            string           `json:"name" mapstructure:"name"`
+	Personal        bool             `json:"personal" mapstructure:"personal"`
+	ResponsesCount  int              `json:"responses_count" mapstructure:"-"`
+	UpdatedAt       Time             `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// CannedResponse is a reusable reply, ContentHTML holds its body with any placeholders still in place
+type CannedResponse struct {
+	Attachments []Attachment `json:"attachments" mapstructure:"-"`
+	Content     string       `json:"content" mapstructure:"content"`
+	ContentHTML string       `json:"content_html" mapstructure:"content_html"`
+	CreatedAt   Time         `json:"created_at" mapstructure:"created_at"`
+	FolderID    int          `json:"folder_id" mapstructure:"folder_id"`
+	ID          int          `json:"id" mapstructure:"id"`
+	Title       string       `json:"title" mapstructure:"title"`
+	UpdatedAt   Time         `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// RequesterPlaceholders returns the ticket.requester placeholder values for a contact
+func RequesterPlaceholders(contact Contact) map[string]string {
+	firstName, lastName, _ := strings.Cut(contact.Name, " ")
+	return map[string]string{
+		"ticket.requester.address":   contact.Address,
+		"ticket.requester.email":     contact.Email,
+		"ticket.requester.firstname": firstName,
+		"ticket.requester.id":        strconv.Itoa(contact.ID),
+		"ticket.requester.language":  contact.Language,
+		"ticket.requester.lastname":  lastName,
+		"ticket.requester.mobile":    contact.Mobile,
+		"ticket.requester.name":      contact.Name,
+		"ticket.requester.phone":     contact.Phone,
+		"ticket.requester.time_zone": contact.TimeZone,
+	}
+}
+
+// RenderPlaceholders replaces the placeholders in an HTML body with their HTML escaped values. Like Liquid, a
+// placeholder without a value renders as its default, or as nothing.
+func RenderPlaceholders(contentHTML string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(contentHTML, func(placeholder string) string {
+		match := placeholderPattern.FindStringSubmatch(placeholder)
+		if value := values[match[1]]; value != "" {
+			return html.EscapeString(value)
+		}
+		// The default is part of the template, so it is already HTML
+		return match[2] + match[3]
+	})
+}
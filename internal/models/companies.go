@@ -0,0 +1,11 @@
+package models
+
+// Company is a Freshdesk company, an organisation that contacts belong to
+type Company struct {
+	CreatedAt   Time     `json:"created_at" mapstructure:"created_at"`
+	Description string   `json:"description" mapstructure:"description"`
+	Domains     []string `json:"domains" mapstructure:"domains"`
+	ID          int      `json:"id" mapstructure:"id"`
+	Name        string   `json:"name" mapstructure:"name"`
+	UpdatedAt   Time     `json:"updated_at" mapstructure:"updated_at"`
+}
@@ -1,22 +1,17 @@
 package models
 
 import (
-	"fmt"
-)
-
-var (
-	ErrFieldAtLeastOneSet     = fmt.Errorf("at least one of these fields must be set")
-	ErrFieldRequired          = fmt.Errorf("field is required")
-	ErrFieldValueMustBeUnique = fmt.Errorf("field must have a unique value")
+	"encoding/json"
 )
 
 // Contact contains the unmarshalled data for a "FreshDesk" contact
 type Contact struct {
-	Active         bool                    `json:"active,omitempty" mapstructure:"active,omitempty"`
+	Active         bool                    `json:"active" mapstructure:"active,omitempty"`
 	Address        string                  `json:"address,omitempty" mapstructure:"address,omitempty"`
 	Avatar         ContactAvatar           `json:"avatar,omitempty" mapstructure:"avatar,omitempty"`
+	Blocked        bool                    `json:"blocked,omitempty" mapstructure:"blocked,omitempty"`
 	CompanyID      int                     `json:"company_id,omitempty" mapstructure:"company_id,omitempty"`
-	CreatedAt      string                  `json:"created_at,omitempty" mapstructure:"created_at,omitempty"`
+	CreatedAt      Time                    `json:"created_at" mapstructure:"created_at,omitempty"`
 	CustomFields   ContactCustomFields     `json:"custom_fields,omitempty" mapstructure:"custom_fields,omitempty"`
 	Deleted        bool                    `json:"deleted,omitempty" mapstructure:"deleted,omitempty"`
 	Description    string                  `json:"description,omitempty" mapstructure:"description,omitempty"`
@@ -27,17 +22,42 @@ type Contact struct {
 	Language       string                  `json:"language,omitempty" mapstructure:"language,omitempty"`
 	Mobile         string                  `json:"mobile,omitempty" mapstructure:"mobile,omitempty"`
 	Name           string                  `json:"name,omitempty" mapstructure:"name,omitempty"`
-	OtherCompanies []ContactOtherCompanies `json:"other_companies,omitempty"`
+	OtherCompanies []ContactOtherCompanies `json:"other_companies,omitempty" mapstructure:"other_companies,omitempty"`
 	OtherEmails    []string                `json:"other_emails,omitempty" mapstructure:"other_emails,omitempty"`
 	PeopleID       string                  `json:"unique_external_id,omitempty" mapstructure:"unique_external_id,omitempty"`
 	Phone          string                  `json:"phone,omitempty" mapstructure:"phone,omitempty"`
 	Tags           []interface{}           `json:"tags,omitempty" mapstructure:"tags,omitempty"`
 	TimeZone       string                  `json:"time_zone,omitempty" mapstructure:"time_zone,omitempty"`
 	TwitterID      string                  `json:"twitter_id,omitempty" mapstructure:"twitter_id,omitempty"`
-	UpdatedAt      string                  `json:"updated_at,omitempty" mapstructure:"updated_at,omitempty"`
+	UpdatedAt      Time                    `json:"updated_at" mapstructure:"updated_at,omitempty"`
 	ViewAllTickets *bool                   `json:"view_all_tickets,omitempty" mapstructure:"view_all_tickets,omitempty"`
 }
 
+const (
+	ContactStateBlocked    = "blocked"
+	ContactStateDeleted    = "deleted"
+	ContactStateUnverified = "unverified"
+	ContactStateVerified   = "verified"
+)
+
+// ContactStates are the values of the contact list's state filter
+var ContactStates = []string{ContactStateBlocked, ContactStateDeleted, ContactStateUnverified, ContactStateVerified}
+
+// State returns which one of ContactStates the contact is in, a deleted contact counts as deleted even if it was
+// also blocked
+func (c Contact) State() string {
+	switch {
+	case c.Deleted:
+		return ContactStateDeleted
+	case c.Blocked:
+		return ContactStateBlocked
+	case c.Active:
+		return ContactStateVerified
+	default:
+		return ContactStateUnverified
+	}
+}
+
 type ContactOtherCompanies struct {
 	CompanyID      int  `json:"company_id" mapstructure:"company_id"`
 	ViewAllTickets bool `json:"view_all_tickets" mapstructure:"view_all_tickets"`
@@ -50,8 +70,31 @@ type ContactAvatar struct {
 	Id          int    `json:"id,omitempty" mapstructure:"id,omitempty"`
 	Name        string `json:"name,omitempty" mapstructure:"name,omitempty"`
 	Size        int    `json:"size,omitempty" mapstructure:"size,omitempty"`
-	CreatedAt   string `json:"created_at,omitempty" mapstructure:"created_at,omitempty"`
-	UpdatedAt   string `json:"updated_at,omitempty" mapstructure:"updated_at,omitempty"`
+	CreatedAt   Time   `json:"created_at" mapstructure:"created_at,omitempty"`
+	UpdatedAt   Time   `json:"updated_at" mapstructure:"updated_at,omitempty"`
+}
+
+// MarshalJSON leaves out the timestamps of a contact that doesn't have them, such as a seed contact without them
+func (c Contact) MarshalJSON() ([]byte, error) {
+	type plainContact Contact
+	return json.Marshal(struct {
+		plainContact
+		CreatedAt *Time `json:"created_at,omitempty"`
+		UpdatedAt *Time `json:"updated_at,omitempty"`
+	}{plainContact(c), optionalTime(c.CreatedAt), optionalTime(c.UpdatedAt)})
+}
+
+// MarshalJSON writes a missing avatar as null, the way Freshdesk does, and leaves out unset timestamps
+func (avatar ContactAvatar) MarshalJSON() ([]byte, error) {
+	if avatar == (ContactAvatar{}) {
+		return []byte("null"), nil
+	}
+	type plainAvatar ContactAvatar
+	return json.Marshal(struct {
+		plainAvatar
+		CreatedAt *Time `json:"created_at,omitempty"`
+		UpdatedAt *Time `json:"updated_at,omitempty"`
+	}{plainAvatar(avatar), optionalTime(avatar.CreatedAt), optionalTime(avatar.UpdatedAt)})
 }
 
 // ContactCustomFields contains the values for the custom_fields portion of a Contact
@@ -65,81 +108,118 @@ type ContactCustomFields struct {
 	Phone2            string `json:"phone_2,omitempty" mapstructure:"phone_2,omitempty"`
 }
 
-func (c Contact) IsValid(existingContacts map[int]Contact) (invalidFields []string, isValid bool, err error) {
-	isValid = true
+// ContactIndex looks up contact IDs by the values of the fields that must be unique across contacts, so many new
+// contacts can be validated without scanning every existing contact for each of them
+type ContactIndex struct {
+	emails     map[string][]int
+	peopleIDs  map[string][]int
+	twitterIDs map[string][]int
+}
 
-	invalidFields, isValid = c.listInvalidUniqueFields(existingContacts)
-	if !isValid {
-		err = ErrFieldValueMustBeUnique
-		return
+// NewContactIndex returns an index of the unique fields of contacts
+func NewContactIndex(contacts []Contact) *ContactIndex {
+	index := &ContactIndex{
+		emails:     map[string][]int{},
+		peopleIDs:  map[string][]int{},
+		twitterIDs: map[string][]int{},
 	}
-
-	invalidFields, isValid = c.listInvalidRequiredFields()
-	if !isValid {
-		err = ErrFieldRequired
-		return
+	for _, contact := range contacts {
+		index.Add(contact)
 	}
+	return index
+}
 
-	invalidFields, isValid = c.listInvalidAtLeastOneFields()
-	if !isValid {
-		err = ErrFieldAtLeastOneSet
-		return
+// Add indexes the unique fields of a contact
+func (index *ContactIndex) Add(c Contact) {
+	if c.Email != "" {
+		index.emails[c.Email] = append(index.emails[c.Email], c.ID)
+	}
+	if c.PeopleID != "" {
+		index.peopleIDs[c.PeopleID] = append(index.peopleIDs[c.PeopleID], c.ID)
+	}
+	if c.TwitterID != "" {
+		index.twitterIDs[c.TwitterID] = append(index.twitterIDs[c.TwitterID], c.ID)
 	}
-
-	return
 }
 
-func (c Contact) listInvalidAtLeastOneFields() (invalidFields []string, isValid bool) {
-	isValid = true
+// IsValid checks the contact against the Freshdesk field rules and returns every problem found
+func (c Contact) IsValid(existing *ContactIndex) (fieldErrors FieldErrors, isValid bool) {
+	fieldErrors = append(fieldErrors, c.listInvalidUniqueFields(existing)...)
+	fieldErrors = append(fieldErrors, c.listInvalidRequiredFields()...)
+	fieldErrors = append(fieldErrors, c.listInvalidAtLeastOneFields()...)
+	fieldErrors = append(fieldErrors, c.listInvalidFormatFields()...)
+
+	return fieldErrors, len(fieldErrors) == 0
+}
 
+func (c Contact) listInvalidAtLeastOneFields() (fieldErrors FieldErrors) {
 	if c.Email == "" &&
 		c.Phone == "" &&
 		c.Mobile == "" &&
 		c.TwitterID == "" &&
-		c.ExternalID == "" {
-
-		isValid = false
-		invalidFields = []string{
-			"email",
-			"phone",
-			"twitter_id",
-			"unique_external_id",
+		c.PeopleID == "" {
+
+		msg := "Please fill at least 1 of email, mobile, phone, twitter_id, unique_external_id fields"
+		for _, field := range []string{"email", "mobile", "phone", "twitter_id", "unique_external_id"} {
+			fieldErrors = append(fieldErrors, NewMissingField(field, msg))
 		}
 	}
 	return
 }
 
-func (c Contact) listInvalidUniqueFields(existingContacts map[int]Contact) (invalidFields []string, isValid bool) {
-	isValid = true
-	for _, contact := range existingContacts {
-		// Don't bother matching against the current version of the contact during an update operation
-		if contact.ID == c.ID {
-			continue
-		}
-		if contact.Email == c.Email && c.Email != "" {
-			invalidFields = append(invalidFields, "email")
-		}
-		if contact.TwitterID == c.TwitterID && c.TwitterID != "" {
-			invalidFields = append(invalidFields, "twitter_id")
-		}
-		if contact.ExternalID == c.ExternalID && c.ExternalID != "" {
-			invalidFields = append(invalidFields, "unique_external_id")
-		}
+func (c Contact) listInvalidUniqueFields(existing *ContactIndex) (fieldErrors FieldErrors) {
+	// Report in a stable order
+	if usedByOther(existing.emails[c.Email], c.ID) {
+		fieldErrors = append(fieldErrors, NewDuplicateValue("email"))
+	}
+	if usedByOther(existing.twitterIDs[c.TwitterID], c.ID) {
+		fieldErrors = append(fieldErrors, NewDuplicateValue("twitter_id"))
+	}
+	if usedByOther(existing.peopleIDs[c.PeopleID], c.ID) {
+		fieldErrors = append(fieldErrors, NewDuplicateValue("unique_external_id"))
 	}
-	if len(invalidFields) > 0 {
-		isValid = false
+	return
+}
+
+// usedByOther reports whether any of the contact IDs holding a value is not id. During an update the contact's
+// own current value doesn't count.
+func usedByOther(ids []int, id int) bool {
+	for _, other := range ids {
+		if other != id {
+			return true
+		}
 	}
-	return invalidFields, isValid
+	return false
 }
 
-func (c Contact) listInvalidRequiredFields() (invalidFields []string, isValid bool) {
-	isValid = true
+func (c Contact) listInvalidRequiredFields() (fieldErrors FieldErrors) {
 	if c.Name == "" {
-		invalidFields = append(invalidFields, "name")
+		fieldErrors = append(fieldErrors, NewMissingField("name", "It should be a/an String"))
 	}
+	return
+}
+
+func (c Contact) listInvalidFormatFields() (fieldErrors FieldErrors) {
+	const emailMsg = "It should be in the 'valid email address' format"
 
-	if len(invalidFields) > 0 {
-		isValid = false
+	if c.Email != "" && !isValidEmail(c.Email) {
+		fieldErrors = append(fieldErrors, NewInvalidValue("email", emailMsg))
+	}
+	for _, otherEmail := range c.OtherEmails {
+		if !isValidEmail(otherEmail) {
+			fieldErrors = append(fieldErrors, NewInvalidValue("other_emails", emailMsg))
+			break
+		}
+		if otherEmail == c.Email {
+			fieldErrors = append(fieldErrors, NewInvalidValue("other_emails", "The primary email cannot also be listed in other_emails"))
+			break
+		}
+	}
+	for _, tag := range c.Tags {
+		if _, isString := tag.(string); !isString {
+			fieldErrors = append(fieldErrors, NewInvalidValue("tags", "It should contain elements of type String only"))
+			break
+		}
 	}
 	return
 }
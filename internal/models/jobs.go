@@ -0,0 +1,62 @@
+package models
+
+// Statuses of a background job, also used by the import and export records
+const (
+	JobStatusCompleted  = "completed"
+	JobStatusFailed     = "failed"
+	JobStatusInProgress = "in_progress"
+	JobStatusQueued     = "queued"
+)
+
+// Job is the generic view of any background operation, returned by GET /jobs/:id
+type Job struct {
+	ID        int             `json:"id"`
+	Action    string          `json:"action"`
+	Status    string          `json:"status"`
+	Progress  int             `json:"progress"`
+	CreatedAt Time            `json:"created_at"`
+	UpdatedAt Time            `json:"updated_at"`
+	Data      []JobItemResult `json:"data,omitempty"`
+}
+
+// JobItemResult is the outcome for a single record processed by a bulk job
+type JobItemResult struct {
+	ID      int         `json:"id,omitempty"`
+	Success bool        `json:"success"`
+	Errors  FieldErrors `json:"errors,omitempty"`
+}
+
+// ContactImport tracks a CSV contacts import started with POST /contacts/imports
+type ContactImport struct {
+	ID             int    `json:"id"`
+	Status         string `json:"status"`
+	CreatedAt      Time   `json:"created_at"`
+	CompletedAt    *Time  `json:"completed_at,omitempty"`
+	TotalRecords   int    `json:"total_records"`
+	CreatedRecords int    `json:"created_records"`
+	FailedRecords  int    `json:"failed_records"`
+	FailuresURL    string `json:"failures_url,omitempty"`
+}
+
+// ContactImportFailure is a CSV row that could not be imported, along with why
+type ContactImportFailure struct {
+	Row    int         `json:"row"`
+	Values []string    `json:"values"`
+	Errors FieldErrors `json:"errors"`
+}
+
+// ContactExport tracks a CSV contacts export started with POST /contacts/export
+type ContactExport struct {
+	ID          int                 `json:"id"`
+	Status      string              `json:"status"`
+	CreatedAt   Time                `json:"created_at"`
+	CompletedAt *Time               `json:"completed_at,omitempty"`
+	DownloadURL string              `json:"download_url,omitempty"`
+	Fields      ContactExportFields `json:"fields"`
+}
+
+// ContactExportFields lists the columns of an export, default fields use the contact field names
+type ContactExportFields struct {
+	DefaultFields []string `json:"default_fields"`
+	CustomFields  []string `json:"custom_fields"`
+}
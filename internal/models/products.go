@@ -0,0 +1,104 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	ErrEmailConfigInvalid = fmt.Errorf("email config is invalid")
+)
+
+// Product is a Freshdesk product, a brand with its own support mailboxes and portal
+type Product struct {
+	CreatedAt    Time   `json:"created_at" mapstructure:"created_at"`
+	Description  string `json:"description" mapstructure:"description"`
+	ID           int    `json:"id" mapstructure:"id"`
+	Name         string `json:"name" mapstructure:"name"`
+	PrimaryEmail string `json:"primary_email" mapstructure:"primary_email"`
+	UpdatedAt    Time   `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// EmailConfig is a support mailbox. Mail sent to ToEmail becomes a ticket, and replies are sent from ReplyEmail.
+// A mailbox without a ProductID belongs to the account rather than a product.
+type EmailConfig struct {
+	Active      bool   `json:"active" mapstructure:"active"`
+	CreatedAt   Time   `json:"created_at" mapstructure:"created_at"`
+	GroupID     int    `json:"group_id,omitempty" mapstructure:"group_id"`
+	ID          int    `json:"id" mapstructure:"id"`
+	Name        string `json:"name" mapstructure:"name"`
+	PrimaryRole bool   `json:"primary_role" mapstructure:"primary_role"`
+	ProductID   int    `json:"product_id,omitempty" mapstructure:"product_id"`
+	ReplyEmail  string `json:"reply_email" mapstructure:"reply_email"`
+	ToEmail     string `json:"to_email" mapstructure:"to_email"`
+	UpdatedAt   Time   `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// IsValid checks the product has a name and, if it has one, a valid primary email
+func (product Product) IsValid() FieldErrors {
+	var fieldErrors FieldErrors
+	if product.Name == "" {
+		fieldErrors = append(fieldErrors, NewMissingField("name", "It should be a/an String"))
+	}
+	if product.PrimaryEmail != "" && !isValidEmail(product.PrimaryEmail) {
+		fieldErrors = append(fieldErrors, NewInvalidValue("primary_email", "It should be in the 'valid email address' format"))
+	}
+	return fieldErrors
+}
+
+// HasMailbox reports whether the product's primary email is the reply address of one of its own email configs.
+// A product without a primary email doesn't need one.
+func (product Product) HasMailbox(emailConfigs []EmailConfig) bool {
+	if product.PrimaryEmail == "" {
+		return true
+	}
+	for _, emailConfig := range emailConfigs {
+		if emailConfig.ProductID == product.ID && strings.EqualFold(emailConfig.ReplyEmail, product.PrimaryEmail) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid checks the mailbox is named and both of its addresses are valid. Incoming mail is routed by its to
+// address, so no other mailbox in existing can share it, and only one mailbox can have the primary role.
+func (emailConfig EmailConfig) IsValid(existing []EmailConfig) FieldErrors {
+	const emailMsg = "It should be in the 'valid email address' format"
+
+	var fieldErrors FieldErrors
+	if emailConfig.Name == "" {
+		fieldErrors = append(fieldErrors, NewMissingField("name", "It should be a/an String"))
+	}
+	switch {
+	case emailConfig.ReplyEmail == "":
+		fieldErrors = append(fieldErrors, NewMissingField("reply_email", emailMsg))
+	case !isValidEmail(emailConfig.ReplyEmail):
+		fieldErrors = append(fieldErrors, NewInvalidValue("reply_email", emailMsg))
+	}
+	switch {
+	case emailConfig.ToEmail == "":
+		fieldErrors = append(fieldErrors, NewMissingField("to_email", emailMsg))
+	case !isValidEmail(emailConfig.ToEmail):
+		fieldErrors = append(fieldErrors, NewInvalidValue("to_email", emailMsg))
+	}
+	for _, other := range existing {
+		if other.ID == emailConfig.ID {
+			continue
+		}
+		if emailConfig.ToEmail != "" && strings.EqualFold(other.ToEmail, emailConfig.ToEmail) {
+			fieldErrors = append(fieldErrors, NewDuplicateValue("to_email"))
+		}
+		if emailConfig.PrimaryRole && other.PrimaryRole {
+			fieldErrors = append(fieldErrors, NewInvalidValue("primary_role", "Only one email config can have the primary role"))
+		}
+	}
+	return fieldErrors
+}
+
+// Validate checks the mailbox on its own, the way IsValid does
+func (emailConfig EmailConfig) Validate() error {
+	if fieldErrors := emailConfig.IsValid(nil); len(fieldErrors) > 0 {
+		return fmt.Errorf("%w: email config %d: %s", ErrEmailConfigInvalid, emailConfig.ID, fieldErrors.Error())
+	}
+	return nil
+}
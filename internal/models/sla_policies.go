@@ -0,0 +1,67 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrNoSLATarget = fmt.Errorf("SLA policy has no target for the priority")
+)
+
+// SLAPolicy is a Freshdesk SLA policy, setting how quickly tickets of each priority must be responded to and
+// resolved
+type SLAPolicy struct {
+	Active       bool                 `json:"active" mapstructure:"active"`
+	ApplicableTo SLAApplicableTo      `json:"applicable_to" mapstructure:"applicable_to"`
+	CreatedAt    Time                 `json:"created_at" mapstructure:"created_at"`
+	Description  string               `json:"description" mapstructure:"description"`
+	ID           int                  `json:"id" mapstructure:"id"`
+	IsDefault    bool                 `json:"is_default" mapstructure:"is_default"`
+	Name         string               `json:"name" mapstructure:"name"`
+	Position     int                  `json:"position" mapstructure:"position"`
+	SLATarget    map[string]SLATarget `json:"sla_target" mapstructure:"sla_target"`
+	UpdatedAt    Time                 `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// SLAApplicableTo lists the conditions a ticket must meet for the policy to apply, an empty list matches anything
+type SLAApplicableTo struct {
+	CompanyIDs  []int    `json:"company_ids,omitempty" mapstructure:"company_ids"`
+	GroupIDs    []int    `json:"group_ids,omitempty" mapstructure:"group_ids"`
+	ProductIDs  []int    `json:"product_ids,omitempty" mapstructure:"product_ids"`
+	Sources     []int    `json:"sources,omitempty" mapstructure:"sources"`
+	TicketTypes []string `json:"ticket_types,omitempty" mapstructure:"ticket_types"`
+}
+
+// SLATarget is the time allowed, in seconds, for tickets of one priority. If BusinessHours is set only time within
+// the business hours calendar counts, otherwise the targets run around the clock.
+type SLATarget struct {
+	BusinessHours     bool `json:"business_hours" mapstructure:"business_hours"`
+	EscalationEnabled bool `json:"escalation_enabled" mapstructure:"escalation_enabled"`
+	RespondWithin     int  `json:"respond_within" mapstructure:"respond_within"`
+	ResolveWithin     int  `json:"resolve_within" mapstructure:"resolve_within"`
+}
+
+// SLATargetKey returns the sla_target key for a ticket priority, i.e. priority_4 for urgent
+func SLATargetKey(priority int) string {
+	return fmt.Sprintf("priority_%d", priority)
+}
+
+// DueDates returns when a ticket of the given priority created at createdAt must first be responded to
+// (fr_due_by) and resolved (due_by), using calendar when the target only counts business hours
+func (policy SLAPolicy) DueDates(createdAt time.Time, priority int, calendar BusinessHours) (frDueBy, dueBy time.Time, err error) {
+	target, ok := policy.SLATarget[SLATargetKey(priority)]
+	if !ok {
+		return frDueBy, dueBy, fmt.Errorf("%w: policy %d, priority %d", ErrNoSLATarget, policy.ID, priority)
+	}
+	respond := time.Duration(target.RespondWithin) * time.Second
+	resolve := time.Duration(target.ResolveWithin) * time.Second
+	if !target.BusinessHours {
+		return createdAt.Add(respond).UTC(), createdAt.Add(resolve).UTC(), nil
+	}
+	if frDueBy, err = calendar.AddBusinessTime(createdAt, respond); err != nil {
+		return frDueBy, dueBy, err
+	}
+	dueBy, err = calendar.AddBusinessTime(createdAt, resolve)
+	return frDueBy, dueBy, err
+}
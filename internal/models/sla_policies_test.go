@@ -0,0 +1,50 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// testPolicy has the default SLA policy targets from config/conf.json, apart from priority_2
+var testPolicy = SLAPolicy{
+	ID: 1,
+	SLATarget: map[string]SLATarget{
+		"priority_1": {BusinessHours: true, RespondWithin: 3600, ResolveWithin: 14400},
+		"priority_3": {BusinessHours: true, RespondWithin: 28800, ResolveWithin: 172800},
+		"priority_4": {BusinessHours: false, RespondWithin: 86400, ResolveWithin: 259200},
+	},
+}
+
+func TestSLAPolicyDueDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		createdAt string
+		priority  int
+		wantFrDue string
+		wantDue   string
+	}{
+		{"business hours over the weekend", "2023-02-10T21:30:00Z", 1, "2023-02-13T13:30:00Z", "2023-02-13T16:30:00Z"},
+		{"calendar time", "2023-02-10T21:30:00Z", 4, "2023-02-11T21:30:00Z", "2023-02-13T21:30:00Z"},
+		{"skips a holiday", "2023-12-22T21:00:00Z", 1, "2023-12-22T22:00:00Z", "2023-12-26T16:00:00Z"},
+		{"daylight saving time starts", "2023-03-10T21:00:00Z", 3, "2023-03-13T19:00:00Z", "2023-03-20T14:00:00Z"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frDueBy, dueBy, err := testPolicy.DueDates(mustParseUTC(t, test.createdAt), test.priority, testCalendar)
+			if err != nil {
+				t.Fatalf("DueDates() error = %v", err)
+			}
+			if want := mustParseUTC(t, test.wantFrDue); !frDueBy.Equal(want) {
+				t.Errorf("DueDates() fr_due_by = %v, want %v", frDueBy, want)
+			}
+			if want := mustParseUTC(t, test.wantDue); !dueBy.Equal(want) {
+				t.Errorf("DueDates() due_by = %v, want %v", dueBy, want)
+			}
+		})
+	}
+
+	_, _, err := testPolicy.DueDates(mustParseUTC(t, "2023-02-10T21:30:00Z"), 2, testCalendar)
+	if !errors.Is(err, ErrNoSLATarget) {
+		t.Errorf("DueDates() for a priority without a target error = %v, want ErrNoSLATarget", err)
+	}
+}
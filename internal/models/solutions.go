@@ -0,0 +1,203 @@
+package models
+
+import (
+	"html"
+	"regexp"
+	"strings"
+)
+
+const (
+	// SolutionsPrimaryLanguage is the language categories, folders and articles are created in, every other
+	// supported language is a translation of them
+	SolutionsPrimaryLanguage = "en"
+
+	ArticleStatusDraft     = 1
+	ArticleStatusPublished = 2
+
+	ArticleTypePermanent  = 1
+	ArticleTypeWorkaround = 2
+
+	FolderVisibilityAllUsers          = 1
+	FolderVisibilityLoggedInUsers     = 2
+	FolderVisibilityAgents            = 3
+	FolderVisibilitySelectedCompanies = 4
+)
+
+var (
+	// SolutionLanguages are the language codes Freshdesk can translate the knowledge base into
+	SolutionLanguages = []string{
+		"ar", "bs", "ca", "cs", "cy-GB", "da", "de", "el", "en", "es", "es-LA", "et", "fi", "fil", "fr", "he", "hr",
+		"hu", "id", "is", "it", "ja-JP", "ko", "lt", "lv-LV", "ms", "nb-NO", "nl", "pl", "pt-BR", "pt-PT", "ro",
+		"ru-RU", "sk", "sl", "sr", "sv-SE", "th", "tr", "uk", "vi", "zh-CN", "zh-TW",
+	}
+
+	// blockTagPattern matches the tags that separate words, unlike inline tags such as <b>
+	blockTagPattern   = regexp.MustCompile(`(?i)</?(blockquote|br|div|h[1-6]|hr|li|ol|p|pre|table|td|th|tr|ul)\b[^>]*>`)
+	htmlTagPattern    = regexp.MustCompile(`<[^>]*>`)
+	whitespacePattern = regexp.MustCompile(`\s+`)
+)
+
+// SolutionCategory is the top level of the knowledge base, holding folders
+type SolutionCategory struct {
+	CreatedAt        Time   `json:"created_at" mapstructure:"created_at"`
+	Description      string `json:"description" mapstructure:"description"`
+	ID               int    `json:"id" mapstructure:"id"`
+	Name             string `json:"name" mapstructure:"name"`
+	UpdatedAt        Time   `json:"updated_at" mapstructure:"updated_at"`
+	VisibleInPortals []int  `json:"visible_in_portals,omitempty" mapstructure:"visible_in_portals"`
+}
+
+// SolutionFolder groups articles within a category, and sets who can see them
+type SolutionFolder struct {
+	CategoryID  int    `json:"category_id" mapstructure:"category_id"`
+	CompanyIDs  []int  `json:"company_ids,omitempty" mapstructure:"company_ids"`
+	CreatedAt   Time   `json:"created_at" mapstructure:"created_at"`
+	Description string `json:"description" mapstructure:"description"`
+	ID          int    `json:"id" mapstructure:"id"`
+	Name        string `json:"name" mapstructure:"name"`
+	UpdatedAt   Time   `json:"updated_at" mapstructure:"updated_at"`
+	Visibility  int    `json:"visibility" mapstructure:"visibility"`
+}
+
+// SolutionArticle is a knowledge base article, Description holds its HTML body
+type SolutionArticle struct {
+	AgentID         int      `json:"agent_id,omitempty" mapstructure:"agent_id"`
+	CategoryID      int      `json:"category_id" mapstructure:"category_id"`
+	CreatedAt       Time     `json:"created_at" mapstructure:"created_at"`
+	Description     string   `json:"description" mapstructure:"description"`
+	DescriptionText string   `json:"description_text" mapstructure:"description_text"`
+	FeedbackCount   int      `json:"feedback_count" mapstructure:"feedback_count"`
+	FolderID        int      `json:"folder_id" mapstructure:"folder_id"`
+	Hits            int      `json:"hits" mapstructure:"hits"`
+	ID              int      `json:"id" mapstructure:"id"`
+	SEOData         SEOData  `json:"seo_data" mapstructure:"seo_data"`
+	Status          int      `json:"status" mapstructure:"status"`
+	Tags            []string `json:"tags" mapstructure:"tags"`
+	ThumbsDown      int      `json:"thumbs_down" mapstructure:"thumbs_down"`
+	ThumbsUp        int      `json:"thumbs_up" mapstructure:"thumbs_up"`
+	Title           string   `json:"title" mapstructure:"title"`
+	Type            int      `json:"type" mapstructure:"type"`
+	UpdatedAt       Time     `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// SEOData is the search engine metadata of an article's portal page
+type SEOData struct {
+	MetaDescription string `json:"meta_description,omitempty" mapstructure:"meta_description"`
+	MetaKeywords    string `json:"meta_keywords,omitempty" mapstructure:"meta_keywords"`
+	MetaTitle       string `json:"meta_title,omitempty" mapstructure:"meta_title"`
+}
+
+// IsSolutionLanguage reports whether language is one of SolutionLanguages
+func IsSolutionLanguage(language string) bool {
+	for _, supported := range SolutionLanguages {
+		if language == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValid checks the category has a name that no other category uses
+func (category SolutionCategory) IsValid(existing []SolutionCategory) FieldErrors {
+	var fieldErrors FieldErrors
+	if category.Name == "" {
+		fieldErrors = append(fieldErrors, NewMissingField("name", "It should be a/an String"))
+	}
+	for _, other := range existing {
+		if other.ID != category.ID && category.Name != "" && strings.EqualFold(other.Name, category.Name) {
+			fieldErrors = append(fieldErrors, NewDuplicateValue("name"))
+			break
+		}
+	}
+	return fieldErrors
+}
+
+// IsValid checks the folder has a name that is unique within its category, and that company_ids are given only
+// when the folder is visible to selected companies
+func (folder SolutionFolder) IsValid(existing []SolutionFolder) FieldErrors {
+	var fieldErrors FieldErrors
+	if folder.Name == "" {
+		fieldErrors = append(fieldErrors, NewMissingField("name", "It should be a/an String"))
+	}
+	for _, other := range existing {
+		if other.ID != folder.ID && other.CategoryID == folder.CategoryID && folder.Name != "" && strings.EqualFold(other.Name, folder.Name) {
+			fieldErrors = append(fieldErrors, NewDuplicateValue("name"))
+			break
+		}
+	}
+	switch {
+	case folder.Visibility < FolderVisibilityAllUsers || folder.Visibility > FolderVisibilitySelectedCompanies:
+		fieldErrors = append(fieldErrors, NewInvalidValue("visibility", "It should be one of these values: '1,2,3,4'"))
+	case folder.Visibility == FolderVisibilitySelectedCompanies && len(folder.CompanyIDs) == 0:
+		fieldErrors = append(fieldErrors, NewMissingField("company_ids", "It should be a/an Array"))
+	case folder.Visibility != FolderVisibilitySelectedCompanies && len(folder.CompanyIDs) > 0:
+		fieldErrors = append(fieldErrors, NewInvalidValue("company_ids", "It can only be set when visibility is 4"))
+	}
+	for _, companyID := range folder.CompanyIDs {
+		if companyID < 1 {
+			fieldErrors = append(fieldErrors, NewInvalidValue("company_ids", "It should contain elements of type Positive Integer only"))
+			break
+		}
+	}
+	return fieldErrors
+}
+
+// IsValid checks the article has a title, a body and a known status and type
+func (article SolutionArticle) IsValid() FieldErrors {
+	var fieldErrors FieldErrors
+	if article.Title == "" {
+		fieldErrors = append(fieldErrors, NewMissingField("title", "It should be a/an String"))
+	}
+	if article.Description == "" {
+		fieldErrors = append(fieldErrors, NewMissingField("description", "It should be a/an String"))
+	}
+	if article.Status != ArticleStatusDraft && article.Status != ArticleStatusPublished {
+		fieldErrors = append(fieldErrors, NewInvalidValue("status", "It should be one of these values: '1,2'"))
+	}
+	if article.Type != ArticleTypePermanent && article.Type != ArticleTypeWorkaround {
+		fieldErrors = append(fieldErrors, NewInvalidValue("type", "It should be one of these values: '1,2'"))
+	}
+	return fieldErrors
+}
+
+// WithTranslation returns the category with the translatable fields of translation in place of its own
+func (category SolutionCategory) WithTranslation(translation SolutionCategory) SolutionCategory {
+	category.Description = translation.Description
+	category.Name = translation.Name
+	category.UpdatedAt = translation.UpdatedAt
+	return category
+}
+
+// WithTranslation returns the folder with the translatable fields of translation in place of its own
+func (folder SolutionFolder) WithTranslation(translation SolutionFolder) SolutionFolder {
+	folder.Description = translation.Description
+	folder.Name = translation.Name
+	folder.UpdatedAt = translation.UpdatedAt
+	return folder
+}
+
+// WithTranslation returns the article with the translatable fields of translation in place of its own. Each
+// translation is published separately, and has its own feedback.
+func (article SolutionArticle) WithTranslation(translation SolutionArticle) SolutionArticle {
+	article.Description = translation.Description
+	article.DescriptionText = translation.DescriptionText
+	article.FeedbackCount = translation.FeedbackCount
+	article.Hits = translation.Hits
+	article.SEOData = translation.SEOData
+	article.Status = translation.Status
+	article.Tags = translation.Tags
+	article.ThumbsDown = translation.ThumbsDown
+	article.ThumbsUp = translation.ThumbsUp
+	article.Title = translation.Title
+	article.UpdatedAt = translation.UpdatedAt
+	return article
+}
+
+// HTMLToText returns the text content of an HTML fragment with its whitespace collapsed, which is how Freshdesk
+// fills in an article's description_text
+func HTMLToText(fragment string) string {
+	text := blockTagPattern.ReplaceAllString(fragment, " ")
+	text = htmlTagPattern.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	return strings.TrimSpace(whitespacePattern.ReplaceAllString(text, " "))
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+)
+
+const (
+	// SurveyDefaultQuestionID is the ID Freshdesk gives the main question of every survey
+	SurveyDefaultQuestionID = "default_question"
+)
+
+var (
+	ErrSurveyInvalid  = fmt.Errorf("survey is invalid")
+	ErrRatingsInvalid = fmt.Errorf("satisfaction ratings don't match the survey")
+
+	// SurveyRatingScale is every rating Freshdesk uses, from extremely happy (103) down to extremely unhappy (-103).
+	// A question's accepted ratings are a subset of these, a three point survey accepts 103, 100 and -103.
+	SurveyRatingScale = []int{103, 102, 101, 100, -101, -102, -103}
+)
+
+// Survey is a Freshdesk customer satisfaction survey, only one survey is active at a time
+type Survey struct {
+	Active    bool             `json:"active" mapstructure:"active"`
+	CreatedAt Time             `json:"created_at" mapstructure:"created_at"`
+	ID        int              `json:"id" mapstructure:"id"`
+	Questions []SurveyQuestion `json:"questions" mapstructure:"questions"`
+	Title     string           `json:"title" mapstructure:"title"`
+	UpdatedAt Time             `json:"updated_at" mapstructure:"updated_at"`
+}
+
+// SurveyQuestion is one question of a survey and the ratings it can be answered with
+type SurveyQuestion struct {
+	AcceptedRatings []int  `json:"accepted_ratings" mapstructure:"accepted_ratings"`
+	ID              string `json:"id" mapstructure:"id"`
+	Label           string `json:"label" mapstructure:"label"`
+}
+
+// SatisfactionRating is a customer's answers to a survey about one of their tickets, Ratings maps question IDs to
+// the rating given
+type SatisfactionRating struct {
+	AgentID   int            `json:"agent_id,omitempty" mapstructure:"agent_id"`
+	CreatedAt Time           `json:"created_at" mapstructure:"created_at"`
+	Feedback  string         `json:"feedback" mapstructure:"feedback"`
+	GroupID   int            `json:"group_id,omitempty" mapstructure:"group_id"`
+	ID        int            `json:"id" mapstructure:"id"`
+	Ratings   map[string]int `json:"ratings" mapstructure:"ratings"`
+	SurveyID  int            `json:"survey_id" mapstructure:"survey_id"`
+	TicketID  int            `json:"ticket_id" mapstructure:"ticket_id"`
+	UpdatedAt Time           `json:"updated_at" mapstructure:"updated_at"`
+	UserID    int            `json:"user_id" mapstructure:"user_id"`
+}
+
+// Validate checks the survey starts with the default question, its question IDs are unique and every accepted
+// rating is on the Freshdesk scale
+func (survey Survey) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: survey %d: %s", ErrSurveyInvalid, survey.ID, fmt.Sprintf(format, args...))
+	}
+
+	if len(survey.Questions) == 0 || survey.Questions[0].ID != SurveyDefaultQuestionID {
+		return invalid("the first question must be %s", SurveyDefaultQuestionID)
+	}
+	ids := map[string]bool{}
+	for _, question := range survey.Questions {
+		if question.ID == "" || ids[question.ID] {
+			return invalid("question ids must be set and unique, %q is not", question.ID)
+		}
+		ids[question.ID] = true
+		if len(question.AcceptedRatings) == 0 {
+			return invalid("question %s has no accepted_ratings", question.ID)
+		}
+		for _, rating := range question.AcceptedRatings {
+			if !containsInt(SurveyRatingScale, rating) {
+				return invalid("question %s: %d is not one of %v", question.ID, rating, SurveyRatingScale)
+			}
+		}
+	}
+	return nil
+}
+
+// CheckRatings checks ratings answers the default question and only answers the survey's questions, each with one
+// of the ratings it accepts
+func (survey Survey) CheckRatings(ratings map[string]int) error {
+	if _, ok := ratings[SurveyDefaultQuestionID]; !ok {
+		return fmt.Errorf("%w: %s must be rated", ErrRatingsInvalid, SurveyDefaultQuestionID)
+	}
+	for questionID, rating := range ratings {
+		var question *SurveyQuestion
+		for idx := range survey.Questions {
+			if survey.Questions[idx].ID == questionID {
+				question = &survey.Questions[idx]
+			}
+		}
+		if question == nil {
+			return fmt.Errorf("%w: survey %d has no question %q", ErrRatingsInvalid, survey.ID, questionID)
+		}
+		if !containsInt(question.AcceptedRatings, rating) {
+			return fmt.Errorf("%w: %s should be one of %v", ErrRatingsInvalid, questionID, question.AcceptedRatings)
+		}
+	}
+	return nil
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// TimeLayout is the format of every timestamp in the Freshdesk API, RFC3339 in UTC with no fractional seconds
+const TimeLayout = "2006-01-02T15:04:05Z"
+
+var (
+	ErrInvalidTime = fmt.Errorf("timestamp must be in the RFC3339 format, i.e. 2023-01-02T15:04:05Z")
+)
+
+// Time is a timestamp that is always written in TimeLayout. The zero Time is written as null.
+type Time struct {
+	time.Time
+}
+
+// NewTime returns t as a Time in UTC, truncated to the second
+func NewTime(t time.Time) Time {
+	return Time{Time: t.UTC().Truncate(time.Second)}
+}
+
+// ParseTime reads an RFC3339 timestamp, any offset is converted to UTC
+func ParseTime(value string) (Time, error) {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return Time{}, fmt.Errorf("%w: %q", ErrInvalidTime, value)
+	}
+	return NewTime(parsed), nil
+}
+
+// optionalTime returns nil for the zero Time, so an omitempty field of type *Time leaves it out
+func optionalTime(t Time) *Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(TimeLayout)
+}
+
+func (t Time) MarshalJSON() ([]byte, error) {
+	if t.IsZero() {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + t.String() + `"`), nil
+}
+
+// UnmarshalJSON accepts an RFC3339 string, or null or "" for the zero Time
+func (t *Time) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("%w: %s", ErrInvalidTime, data)
+	}
+	return t.UnmarshalText(data[1 : len(data)-1])
+}
+
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText is used when reading timestamps from config files and form values, "" is the zero Time
+func (t *Time) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*t = Time{}
+		return nil
+	}
+	parsed, err := ParseTime(string(data))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
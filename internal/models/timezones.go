@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+var (
+	ErrUnknownTimeZone = fmt.Errorf("unknown time zone")
+
+	// railsTimeZones maps the time zone names Freshdesk uses, which come from Rails, to IANA names. Only the most
+	// common ones are listed, any IANA name is accepted as well.
+	railsTimeZones = map[string]string{
+		"Alaska":                      "America/Juneau",
+		"Amsterdam":                   "Europe/Amsterdam",
+		"Arizona":                     "America/Phoenix",
+		"Athens":                      "Europe/Athens",
+		"Atlantic Time (Canada)":      "America/Halifax",
+		"Berlin":                      "Europe/Berlin",
+		"Brisbane":                    "Australia/Brisbane",
+		"Central Time (US & Canada)":  "America/Chicago",
+		"Chennai":                     "Asia/Kolkata",
+		"Dublin":                      "Europe/Dublin",
+		"Eastern Time (US & Canada)":  "America/New_York",
+		"Hawaii":                      "Pacific/Honolulu",
+		"Hong Kong":                   "Asia/Hong_Kong",
+		"Kolkata":                     "Asia/Kolkata",
+		"London":                      "Europe/London",
+		"Madrid":                      "Europe/Madrid",
+		"Mexico City":                 "America/Mexico_City",
+		"Mountain Time (US & Canada)": "America/Denver",
+		"Mumbai":                      "Asia/Kolkata",
+		"New Delhi":                   "Asia/Kolkata",
+		"Pacific Time (US & Canada)":  "America/Los_Angeles",
+		"Paris":                       "Europe/Paris",
+		"Singapore":                   "Asia/Singapore",
+		"Sydney":                      "Australia/Sydney",
+		"Tokyo":                       "Asia/Tokyo",
+		"UTC":                         "UTC",
+	}
+)
+
+// LoadTimeZone returns the location for a Freshdesk (Rails) or IANA time zone name, an empty name is UTC
+func LoadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	if iana, ok := railsTimeZones[name]; ok {
+		name = iana
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownTimeZone, name)
+	}
+	return location, nil
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// Error codes returned in the errors list of a Freshdesk validation failure
+const (
+	CodeDatatypeMismatch = "datatype_mismatch"
+	CodeDuplicateValue   = "duplicate_value"
+	CodeInvalidField     = "invalid_field"
+	CodeInvalidValue     = "invalid_value"
+	CodeMissingField     = "missing_field"
+)
+
+// FieldError describes a single field level validation problem
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+	Code    string `json:"code"`
+}
+
+// FieldErrors is the full set of validation problems found for a single record
+type FieldErrors []FieldError
+
+func (fe FieldErrors) Error() string {
+	msgs := make([]string, 0, len(fe))
+	for _, e := range fe {
+		msgs = append(msgs, fmt.Sprintf("%s: %s (%s)", e.Field, e.Message, e.Code))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// HasCode reports whether any of the errors use the given code
+func (fe FieldErrors) HasCode(code string) bool {
+	for _, e := range fe {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// OnlyCode reports whether every one of the errors uses the given code
+func (fe FieldErrors) OnlyCode(code string) bool {
+	if len(fe) == 0 {
+		return false
+	}
+	for _, e := range fe {
+		if e.Code != code {
+			return false
+		}
+	}
+	return true
+}
+
+// NewDatatypeMismatch returns the error Freshdesk reports when a field value has the wrong JSON type
+func NewDatatypeMismatch(field, gotType, wantType string) FieldError {
+	return FieldError{
+		Field:   field,
+		Message: fmt.Sprintf("Value set is of type %s.It should be a/an %s", gotType, wantType),
+		Code:    CodeDatatypeMismatch,
+	}
+}
+
+// NewDuplicateValue returns the error Freshdesk reports when a unique field value is already in use
+func NewDuplicateValue(field string) FieldError {
+	return FieldError{
+		Field:   field,
+		Message: "It should be a unique value",
+		Code:    CodeDuplicateValue,
+	}
+}
+
+// NewInvalidField returns the error Freshdesk reports for an unknown or read-only field
+func NewInvalidField(field string) FieldError {
+	return FieldError{
+		Field:   field,
+		Message: "Unexpected/invalid field in request",
+		Code:    CodeInvalidField,
+	}
+}
+
+// NewInvalidValue returns the error Freshdesk reports when a value is of the right type but not acceptable
+func NewInvalidValue(field, message string) FieldError {
+	return FieldError{
+		Field:   field,
+		Message: message,
+		Code:    CodeInvalidValue,
+	}
+}
+
+// NewMissingField returns the error Freshdesk reports when a mandatory field is absent
+func NewMissingField(field, message string) FieldError {
+	return FieldError{
+		Field:   field,
+		Message: message,
+		Code:    CodeMissingField,
+	}
+}
+
+func isValidEmail(address string) bool {
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return false
+	}
+	// ParseAddress accepts "Name <addr>" forms, Freshdesk only accepts the bare address
+	return parsed.Address == address
+}
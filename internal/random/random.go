@@ -0,0 +1,45 @@
+package random
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Source is a random number generator that is safe for concurrent use. Created with a fixed seed, it produces the
+// same IDs in the same order every run, as long as requests arrive in the same order.
+type Source struct {
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// New returns a Source seeded with seed, or with the current time if seed is 0
+func New(seed int64) *Source {
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Source{rand: rand.New(rand.NewSource(seed))}
+}
+
+// Intn returns a number in the range [0, n)
+func (source *Source) Intn(n int) int {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return source.rand.Intn(n)
+}
+
+// Float64 returns a number in the range [0.0, 1.0)
+func (source *Source) Float64() float64 {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return source.rand.Float64()
+}
+
+// Split returns a new Source seeded from this one. Parts of the server whose draws depend on things other than the
+// order of requests, such as files left over in the blob directory or job timing, get their own Source so their
+// draws don't shift the IDs generated for everything else.
+func (source *Source) Split() *Source {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	return &Source{rand: rand.New(rand.NewSource(source.rand.Int63()))}
+}
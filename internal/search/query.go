@@ -0,0 +1,353 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+// MaxQueryLength is the longest query Freshdesk accepts, not counting the surrounding double quotes
+const MaxQueryLength = 512
+
+var (
+	ErrInvalidQuery = fmt.Errorf("invalid query")
+)
+
+// Query is a parsed filter query, such as "status:2 AND (priority:3 OR priority:4) AND created_at:>'2023-01-01'".
+// Conditions are field:value, field:>value (on or after) or field:<value (on or before), and AND binds tighter
+// than OR. Strings and dates are single quoted, numbers and booleans are bare, and field:null matches an empty field.
+type Query struct {
+	root node
+}
+
+type node interface {
+	match(record reflect.Value) bool
+}
+
+// Parse checks the query against the resource's schema, the surrounding double quotes are optional
+func Parse(schema *Schema, query string) (*Query, error) {
+	query = strings.TrimSpace(query)
+	if len(query) >= 2 && strings.HasPrefix(query, `"`) && strings.HasSuffix(query, `"`) {
+		query = query[1 : len(query)-1]
+	}
+	if strings.TrimSpace(query) == "" {
+		return nil, fmt.Errorf("%w: it is empty", ErrInvalidQuery)
+	}
+	if len(query) > MaxQueryLength {
+		return nil, fmt.Errorf("%w: it is longer than %d characters", ErrInvalidQuery, MaxQueryLength)
+	}
+
+	p := &parser{schema: schema, input: query}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos:])
+	}
+	return &Query{root: root}, nil
+}
+
+// Matches reports whether the record, a value of the schema's model, satisfies the query
+func (q *Query) Matches(record interface{}) bool {
+	return q.root.match(reflect.ValueOf(record))
+}
+
+type parser struct {
+	schema *Schema
+	input  string
+	pos    int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidQuery, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+}
+
+// keyword consumes the operator word (AND or OR) if it is next
+func (p *parser) keyword(word string) bool {
+	p.skipSpace()
+	end := p.pos + len(word)
+	if end > len(p.input) || p.input[p.pos:end] != word {
+		return false
+	}
+	if end < len(p.input) && !unicode.IsSpace(rune(p.input[end])) && p.input[end] != '(' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []node{left}
+	for p.keyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return orNode(nodes), nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	nodes := []node{left}
+	for p.keyword("AND") {
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, right)
+	}
+	if len(nodes) == 1 {
+		return left, nil
+	}
+	return andNode(nodes), nil
+}
+
+func (p *parser) parseFactor() (node, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, p.errorf("expected a condition at the end of the query")
+	}
+	if p.input[p.pos] != '(' {
+		return p.parseCondition()
+	}
+	p.pos++
+	inner, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos >= len(p.input) || p.input[p.pos] != ')' {
+		return nil, p.errorf("missing closing parenthesis")
+	}
+	p.pos++
+	return inner, nil
+}
+
+func (p *parser) parseCondition() (node, error) {
+	start := p.pos
+	for p.pos < len(p.input) && p.input[p.pos] != ':' && !unicode.IsSpace(rune(p.input[p.pos])) && p.input[p.pos] != ')' {
+		p.pos++
+	}
+	name := p.input[start:p.pos]
+	if p.pos >= len(p.input) || p.input[p.pos] != ':' || name == "" {
+		return nil, p.errorf("expected field:value at %q", p.input[start:])
+	}
+	p.pos++
+	field, known := p.schema.Fields[name]
+	if !known {
+		return nil, p.errorf("%s is not a searchable field", name)
+	}
+
+	var operator byte
+	if p.pos < len(p.input) && (p.input[p.pos] == '>' || p.input[p.pos] == '<') {
+		operator = p.input[p.pos]
+		p.pos++
+	}
+	value, quoted, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	return newCondition(name, field, operator, value, quoted)
+}
+
+// parseValue reads a single quoted string (with \' for a quote) or a bare word
+func (p *parser) parseValue() (value string, quoted bool, err error) {
+	if p.pos < len(p.input) && p.input[p.pos] == '\'' {
+		p.pos++
+		var b strings.Builder
+		for p.pos < len(p.input) {
+			c := p.input[p.pos]
+			switch {
+			case c == '\\' && p.pos+1 < len(p.input) && p.input[p.pos+1] == '\'':
+				b.WriteByte('\'')
+				p.pos += 2
+			case c == '\'':
+				p.pos++
+				return b.String(), true, nil
+			default:
+				b.WriteByte(c)
+				p.pos++
+			}
+		}
+		return "", false, p.errorf("missing closing quote")
+	}
+	start := p.pos
+	for p.pos < len(p.input) && !unicode.IsSpace(rune(p.input[p.pos])) && p.input[p.pos] != ')' {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", false, p.errorf("expected a value at %q", p.input[start:])
+	}
+	return p.input[start:p.pos], false, nil
+}
+
+type andNode []node
+
+func (nodes andNode) match(record reflect.Value) bool {
+	for _, n := range nodes {
+		if !n.match(record) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []node
+
+func (nodes orNode) match(record reflect.Value) bool {
+	for _, n := range nodes {
+		if n.match(record) {
+			return true
+		}
+	}
+	return false
+}
+
+// condition compares a field against a value, lo and hi bound numbers and times (either may be open) while
+// strings and booleans are compared for equality
+type condition struct {
+	field  Field
+	isNull bool
+	text   string
+	flag   bool
+	lo, hi *float64
+	from   time.Time
+	until  time.Time
+}
+
+func newCondition(name string, field Field, operator byte, value string, quoted bool) (node, error) {
+	cond := &condition{field: field}
+	if value == "null" && !quoted {
+		if operator != 0 {
+			return nil, fmt.Errorf("%w: %s can't be compared with null", ErrInvalidQuery, name)
+		}
+		cond.isNull = true
+		return cond, nil
+	}
+
+	invalid := func(want string) error {
+		return fmt.Errorf("%w: %s is a %s field, %s", ErrInvalidQuery, name, field.Type, want)
+	}
+	switch field.Type {
+	case TypeString:
+		if !quoted || operator != 0 {
+			return nil, invalid("its value must be in single quotes")
+		}
+		cond.text = value
+	case TypeBoolean:
+		parsed, err := strconv.ParseBool(value)
+		if err != nil || quoted || operator != 0 {
+			return nil, invalid("its value must be true or false")
+		}
+		cond.flag = parsed
+	case TypeNumber:
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || quoted {
+			return nil, invalid("its value must be a number")
+		}
+		if operator != '<' {
+			cond.lo = &parsed
+		}
+		if operator != '>' {
+			cond.hi = &parsed
+		}
+	case TypeDate:
+		if !quoted {
+			return nil, invalid("its value must be a quoted date, i.e. '2023-01-02'")
+		}
+		from, until, err := parseDateRange(value)
+		if err != nil {
+			return nil, invalid("its value must be a quoted date, i.e. '2023-01-02'")
+		}
+		switch operator {
+		case '>':
+			cond.from = from
+		case '<':
+			cond.until = until
+		default:
+			cond.from, cond.until = from, until
+		}
+	}
+	return cond, nil
+}
+
+// parseDateRange returns the span a date (the whole day) or an RFC3339 timestamp (that second) covers,
+// until is exclusive
+func parseDateRange(value string) (from, until time.Time, err error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, date.AddDate(0, 0, 1), nil
+	}
+	timestamp, err := models.ParseTime(value)
+	if err != nil {
+		return from, until, err
+	}
+	return timestamp.Time, timestamp.Add(time.Second), nil
+}
+
+func (cond *condition) match(record reflect.Value) bool {
+	values := cond.field.values(record)
+	if cond.isNull {
+		for _, value := range values {
+			if value.IsValid() && !value.IsZero() {
+				return false
+			}
+		}
+		return true
+	}
+	for _, value := range values {
+		if cond.matchValue(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func (cond *condition) matchValue(value reflect.Value) bool {
+	if !value.IsValid() || !value.CanInterface() {
+		return false
+	}
+	switch cond.field.Type {
+	case TypeString:
+		return strings.EqualFold(fmt.Sprint(value.Interface()), cond.text)
+	case TypeBoolean:
+		return value.Kind() == reflect.Bool && value.Bool() == cond.flag
+	case TypeNumber:
+		number, err := strconv.ParseFloat(fmt.Sprint(value.Interface()), 64)
+		if err != nil {
+			return false
+		}
+		return (cond.lo == nil || number >= *cond.lo) && (cond.hi == nil || number <= *cond.hi)
+	case TypeDate:
+		timestamp, ok := value.Interface().(models.Time)
+		if !ok || timestamp.IsZero() {
+			return false
+		}
+		return (cond.from.IsZero() || !timestamp.Before(cond.from)) && (cond.until.IsZero() || timestamp.Before(cond.until))
+	}
+	return false
+}
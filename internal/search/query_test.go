@@ -0,0 +1,203 @@
+package search
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+type testRecord struct {
+	Active       bool             `json:"active"`
+	CreatedAt    models.Time      `json:"created_at"`
+	CustomFields testCustomFields `json:"custom_fields"`
+	Email        string           `json:"email"`
+	Labels       []interface{}    `json:"labels"`
+	Priority     int              `json:"priority"`
+	Tags         []string         `json:"tags"`
+}
+
+type testCustomFields struct {
+	Benefit string `json:"benefit"`
+	Score   int    `json:"score"`
+}
+
+var testSchema = MustSchema(testRecord{}, map[string]string{
+	"active":     "active",
+	"created_at": "created_at",
+	"email":      "email",
+	"label":      "labels",
+	"priority":   "priority",
+	"tag":        "tags",
+}, "custom_fields")
+
+func testTime(value string) models.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return models.NewTime(parsed)
+}
+
+func TestParseRejectsInvalidQueries(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"empty", `""`},
+		{"blank", "   "},
+		{"too long", "email:'" + strings.Repeat("a", MaxQueryLength) + "'"},
+		{"unknown field", "status:2"},
+		{"missing value", "priority:"},
+		{"missing colon", "priority"},
+		{"unquoted string", "email:a@example.com"},
+		{"quoted number", "priority:'2'"},
+		{"quoted boolean", "active:'true'"},
+		{"not a boolean", "active:yes"},
+		{"unquoted date", "created_at:2023-01-02"},
+		{"invalid date", "created_at:'2023-13-02'"},
+		{"compared string", "email:>'a@example.com'"},
+		{"compared null", "priority:>null"},
+		{"unclosed quote", "email:'a@example.com"},
+		{"unclosed parenthesis", "(priority:1 OR priority:2"},
+		{"trailing operator", "priority:1 AND"},
+		{"trailing text", "priority:1 priority:2"},
+		{"lowercase operator", "priority:1 and priority:2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(testSchema, test.query)
+			if !errors.Is(err, ErrInvalidQuery) {
+				t.Errorf("Parse(%q) error = %v, want ErrInvalidQuery", test.query, err)
+			}
+		})
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	record := testRecord{
+		Active:       true,
+		CreatedAt:    testTime("2023-02-10T16:00:00Z"),
+		CustomFields: testCustomFields{Benefit: "CSNP", Score: 7},
+		Email:        "it's@example.com",
+		Priority:     3,
+		Tags:         []string{"vip", "billing"},
+	}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`"priority:3"`, true},
+		{"priority:2", false},
+		{"priority:>3", true},
+		{"priority:>4", false},
+		{"priority:<3", true},
+		{"priority:<2.5", false},
+		{"active:true", true},
+		{"active:false", false},
+		{`email:'IT\'S@example.com'`, true},
+		{"email:'other@example.com'", false},
+		{"tag:'billing'", true},
+		{"tag:'sales'", false},
+		{"benefit:'csnp'", true},
+		{"score:>5", true},
+		{"benefit:null", false},
+		{"created_at:'2023-02-10'", true},
+		{"created_at:'2023-02-11'", false},
+		{"created_at:'2023-02-10T16:00:00Z'", true},
+		{"created_at:'2023-02-10T16:00:01Z'", false},
+		{"created_at:>'2023-02-10'", true},
+		{"created_at:>'2023-02-11'", false},
+		{"created_at:<'2023-02-10'", true},
+		{"created_at:<'2023-02-09'", false},
+		{"priority:3 AND active:false", false},
+		{"priority:2 OR active:true", true},
+		// AND binds tighter than OR
+		{"priority:2 AND active:true OR tag:'vip'", true},
+		{"priority:2 AND (active:true OR tag:'vip')", false},
+		{"(priority:2 OR priority:3)AND(tag:'sales' OR tag:'vip')", true},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := Parse(testSchema, test.query)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", test.query, err)
+			}
+			if got := query.Matches(record); got != test.want {
+				t.Errorf("Matches() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestQueryMatchesNull(t *testing.T) {
+	query, err := Parse(testSchema, "created_at:null AND tag:null AND benefit:null")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !query.Matches(testRecord{}) {
+		t.Error("Matches() = false for a record with empty fields, want true")
+	}
+	if query.Matches(testRecord{Tags: []string{"vip"}}) {
+		t.Error("Matches() = true for a record with a tag, want false")
+	}
+}
+
+// A list from a JSON body can hold nulls, as in "tags":[null], which have no value to match
+func TestQueryMatchesNullListItems(t *testing.T) {
+	record := testRecord{Labels: []interface{}{nil, "vip"}}
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"label:'vip'", true},
+		{"label:'sales'", false},
+		{"label:null", false},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			query, err := Parse(testSchema, test.query)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", test.query, err)
+			}
+			if got := query.Matches(record); got != test.want {
+				t.Errorf("Matches() = %v, want %v", got, test.want)
+			}
+		})
+	}
+
+	query, err := Parse(testSchema, "label:null")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !query.Matches(testRecord{Labels: []interface{}{nil}}) {
+		t.Error("Matches() = false for a list of only nulls, want true")
+	}
+}
+
+func TestNewSchema(t *testing.T) {
+	wantTypes := map[string]FieldType{
+		"active":     TypeBoolean,
+		"benefit":    TypeString,
+		"created_at": TypeDate,
+		"email":      TypeString,
+		"label":      TypeString,
+		"priority":   TypeNumber,
+		"score":      TypeNumber,
+		"tag":        TypeString,
+	}
+	if len(testSchema.Fields) != len(wantTypes) {
+		t.Errorf("schema has %d fields, want %d", len(testSchema.Fields), len(wantTypes))
+	}
+	for name, want := range wantTypes {
+		if got := testSchema.Fields[name].Type; got != want {
+			t.Errorf("field %s is a %s field, want %s", name, got, want)
+		}
+	}
+
+	if _, err := NewSchema(testRecord{}, map[string]string{"status": "status"}, ""); err == nil {
+		t.Error("NewSchema() with an unknown path succeeded, want an error")
+	}
+}
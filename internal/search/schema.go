@@ -0,0 +1,184 @@
+package search
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+// FieldType is how a searchable field's values are compared
+type FieldType int
+
+const (
+	TypeString FieldType = iota
+	TypeNumber
+	TypeBoolean
+	TypeDate
+)
+
+func (fieldType FieldType) String() string {
+	switch fieldType {
+	case TypeNumber:
+		return "number"
+	case TypeBoolean:
+		return "boolean"
+	case TypeDate:
+		return "date"
+	default:
+		return "string"
+	}
+}
+
+// Field is a searchable field of a resource
+type Field struct {
+	Type FieldType
+	// path is the dotted json path of the field within the resource's model
+	path string
+}
+
+// Schema lists the fields of a resource that can be used in a query, by the name they are searched with
+type Schema struct {
+	Fields map[string]Field
+	model  reflect.Type
+}
+
+var timeType = reflect.TypeOf(models.Time{})
+
+// NewSchema builds the schema for a resource from its model. fields maps each search field name to the dotted json
+// path of the model field it searches, i.e. "tag" to "tags". Every field of the struct at customFieldsPath, if it
+// is not empty, is searchable by its own name the way Freshdesk custom fields are.
+func NewSchema(model interface{}, fields map[string]string, customFieldsPath string) (*Schema, error) {
+	schema := &Schema{
+		Fields: map[string]Field{},
+		model:  reflect.TypeOf(model),
+	}
+	for name, path := range fields {
+		fieldType, err := schema.typeOf(path)
+		if err != nil {
+			return nil, err
+		}
+		schema.Fields[name] = Field{Type: fieldType, path: path}
+	}
+
+	if customFieldsPath != "" {
+		customType, err := fieldByPath(schema.model, customFieldsPath)
+		if err != nil {
+			return nil, err
+		}
+		for i := 0; i < customType.NumField(); i++ {
+			name := jsonName(customType.Field(i))
+			if _, taken := schema.Fields[name]; taken || name == "" {
+				continue
+			}
+			path := customFieldsPath + "." + name
+			fieldType, err := schema.typeOf(path)
+			if err != nil {
+				return nil, err
+			}
+			schema.Fields[name] = Field{Type: fieldType, path: path}
+		}
+	}
+	return schema, nil
+}
+
+// MustSchema is NewSchema for schemas built from fixed field lists, it panics on a path that doesn't exist
+func MustSchema(model interface{}, fields map[string]string, customFieldsPath string) *Schema {
+	schema, err := NewSchema(model, fields, customFieldsPath)
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func (schema *Schema) typeOf(path string) (FieldType, error) {
+	t, err := fieldByPath(schema.model, path)
+	if err != nil {
+		return TypeString, err
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return TypeDate, nil
+	case t.Kind() == reflect.Bool:
+		return TypeBoolean, nil
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Float64:
+		return TypeNumber, nil
+	default:
+		return TypeString, nil
+	}
+}
+
+// values returns the value(s) of the field in a record, a list field gives one value per item
+func (field Field) values(record reflect.Value) []reflect.Value {
+	for _, name := range strings.Split(field.path, ".") {
+		var ok bool
+		if record, ok = indirect(record); !ok {
+			return nil
+		}
+		record = record.FieldByIndex(fieldIndex(record.Type(), name))
+	}
+	record, ok := indirect(record)
+	if !ok {
+		return nil
+	}
+	if record.Kind() != reflect.Slice {
+		return []reflect.Value{record}
+	}
+	items := make([]reflect.Value, 0, record.Len())
+	for i := 0; i < record.Len(); i++ {
+		// A null item, such as the one in "tags":[null], has no value to match
+		if item, ok := indirect(record.Index(i)); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// indirect follows pointers and interfaces to the value they hold, ok is false when one of them is nil
+func indirect(value reflect.Value) (reflect.Value, bool) {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+	return value, true
+}
+
+func fieldByPath(t reflect.Type, path string) (reflect.Type, error) {
+	for _, name := range strings.Split(path, ".") {
+		if t.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("%s is not a field of %s", path, t)
+		}
+		index := fieldIndex(t, name)
+		if index == nil {
+			return nil, fmt.Errorf("%s is not a field of %s", path, t)
+		}
+		t = t.FieldByIndex(index).Type
+	}
+	return t, nil
+}
+
+func fieldIndex(t reflect.Type, name string) []int {
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return []int{i}
+		}
+	}
+	return nil
+}
+
+func jsonName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" {
+		return ""
+	}
+	return name
+}
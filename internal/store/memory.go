@@ -0,0 +1,130 @@
+package store
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+// Memory is a Store that keeps everything in process memory and is lost on exit
+type Memory struct {
+	mu       sync.RWMutex
+	contacts map[int]models.Contact
+	records  map[string]map[int][]byte
+}
+
+// NewMemory returns an empty in-memory Store
+func NewMemory() *Memory {
+	return &Memory{
+		contacts: map[int]models.Contact{},
+		records:  map[string]map[int][]byte{},
+	}
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
+
+func (m *Memory) CountContacts() (int, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.contacts), nil
+}
+
+func (m *Memory) DeleteContact(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.contacts, id)
+	return nil
+}
+
+func (m *Memory) FindContacts(query ContactQuery) ([]models.Contact, error) {
+	all, _ := m.ListContacts()
+	matching := []models.Contact{}
+	for _, contact := range all {
+		if query.Matches(contact) {
+			matching = append(matching, contact)
+		}
+	}
+	return matching, nil
+}
+
+func (m *Memory) GetContact(id int) (models.Contact, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	contact, exists := m.contacts[id]
+	if !exists {
+		return models.Contact{}, ErrRecordNotFound
+	}
+	return contact, nil
+}
+
+// ListContacts returns every contact ordered by ID
+func (m *Memory) ListContacts() ([]models.Contact, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	contacts := make([]models.Contact, 0, len(m.contacts))
+	for _, contact := range m.contacts {
+		contacts = append(contacts, contact)
+	}
+	sort.Slice(contacts, func(i, j int) bool {
+		return contacts[i].ID < contacts[j].ID
+	})
+	return contacts, nil
+}
+
+func (m *Memory) PutContact(contact models.Contact) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contacts[contact.ID] = contact
+	return nil
+}
+
+func (m *Memory) DeleteRecord(kind string, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records[kind], id)
+	return nil
+}
+
+func (m *Memory) GetRecord(kind string, id int, out interface{}) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, exists := m.records[kind][id]
+	if !exists {
+		return ErrRecordNotFound
+	}
+	return json.Unmarshal(data, out)
+}
+
+// ListRecords returns the JSON document of every record of the given kind ordered by ID
+func (m *Memory) ListRecords(kind string) ([][]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]int, 0, len(m.records[kind]))
+	for id := range m.records[kind] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	records := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, m.records[kind][id])
+	}
+	return records, nil
+}
+
+func (m *Memory) PutRecord(kind string, id int, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, exists := m.records[kind]; !exists {
+		m.records[kind] = map[int][]byte{}
+	}
+	m.records[kind][id] = data
+	return nil
+}
@@ -0,0 +1,104 @@
+package store
+
+import (
+	"database/sql"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+type migration struct {
+	Version     int
+	Description string
+	Statements  []string
+}
+
+// migrations are applied in order, each exactly once. Never edit an entry that has been released, add a new one.
+var migrations = []migration{
+	{
+		Version:     1,
+		Description: "create contacts table",
+		Statements: []string{
+			`CREATE TABLE contacts (
+				id                 INTEGER PRIMARY KEY,
+				email              TEXT NOT NULL DEFAULT '',
+				mobile             TEXT NOT NULL DEFAULT '',
+				phone              TEXT NOT NULL DEFAULT '',
+				unique_external_id TEXT NOT NULL DEFAULT '',
+				updated_at         TEXT NOT NULL DEFAULT '',
+				data               TEXT NOT NULL
+			)`,
+			`CREATE INDEX contacts_email ON contacts (email)`,
+			`CREATE INDEX contacts_mobile ON contacts (mobile)`,
+			`CREATE INDEX contacts_phone ON contacts (phone)`,
+			`CREATE INDEX contacts_unique_external_id ON contacts (unique_external_id)`,
+			`CREATE INDEX contacts_updated_at ON contacts (updated_at)`,
+		},
+	},
+	{
+		Version:     2,
+		Description: "create generic records table",
+		Statements: []string{
+			`CREATE TABLE records (
+				kind TEXT    NOT NULL,
+				id   INTEGER NOT NULL,
+				data TEXT    NOT NULL,
+				PRIMARY KEY (kind, id)
+			)`,
+		},
+	},
+}
+
+// migrate brings the database schema up to the latest version
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER PRIMARY KEY,
+		description TEXT NOT NULL,
+		applied_at  TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	var current int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		if m.Version <= current {
+			continue
+		}
+		logLn := log.WithFields(log.Fields{
+			"migration.version":     m.Version,
+			"migration.description": m.Description,
+		})
+		logLn.Info("applying schema migration")
+
+		if err := applyMigration(db, m); err != nil {
+			logLn.WithField("error", err.Error()).Error("schema migration failed")
+			return err
+		}
+	}
+	return nil
+}
+
+func applyMigration(db *sql.DB, m migration) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	for _, statement := range m.Statements {
+		if _, err := tx.Exec(statement); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if _, err := tx.Exec(
+		`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, ?)`,
+		m.Version, m.Description, time.Now().UTC().Format(time.RFC3339),
+	); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
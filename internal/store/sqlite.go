@@ -0,0 +1,219 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/SkyMack/staledesk/internal/models"
+	log "github.com/sirupsen/logrus"
+
+	// Pure Go SQLite driver, registers itself as "sqlite"
+	_ "modernc.org/sqlite"
+)
+
+// SQLite is a Store backed by a single SQLite database file
+type SQLite struct {
+	db *sql.DB
+}
+
+// NewSQLite opens (creating if needed) the database file at path and applies any pending schema migrations
+func NewSQLite(path string) (*SQLite, error) {
+	logLn := log.WithField("database.file", path)
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		logLn.WithField("error", err.Error()).Error(ErrCannotOpen.Error())
+		return nil, ErrCannotOpen
+	}
+	// SQLite only allows a single writer, serialize access rather than fail with SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(`PRAGMA journal_mode = WAL`); err != nil {
+		logLn.WithField("error", err.Error()).Error(ErrCannotOpen.Error())
+		db.Close()
+		return nil, ErrCannotOpen
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, ErrMigrationFail
+	}
+	return &SQLite{db: db}, nil
+}
+
+func (s *SQLite) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLite) CountContacts() (int, error) {
+	var count int
+	err := s.db.QueryRow(`SELECT COUNT(*) FROM contacts`).Scan(&count)
+	return count, err
+}
+
+func (s *SQLite) DeleteContact(id int) error {
+	_, err := s.db.Exec(`DELETE FROM contacts WHERE id = ?`, id)
+	return err
+}
+
+func (s *SQLite) FindContacts(query ContactQuery) ([]models.Contact, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	if query.CompanyID != 0 {
+		conditions = append(conditions, "json_extract(data, '$.company_id') = ?")
+		args = append(args, query.CompanyID)
+	}
+	if query.Email != "" {
+		conditions = append(conditions, "email = ?")
+		args = append(args, query.Email)
+	}
+	if query.Mobile != "" {
+		conditions = append(conditions, "mobile = ?")
+		args = append(args, query.Mobile)
+	}
+	if query.Phone != "" {
+		conditions = append(conditions, "phone = ?")
+		args = append(args, query.Phone)
+	}
+	if len(query.PeopleIDs) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(query.PeopleIDs)), ",")
+		conditions = append(conditions, "unique_external_id IN ("+placeholders+")")
+		for _, peopleID := range query.PeopleIDs {
+			args = append(args, peopleID)
+		}
+	}
+
+	if len(query.States) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(query.States)), ",")
+		conditions = append(conditions, contactStateExpr+" IN ("+placeholders+")")
+		for _, state := range query.States {
+			args = append(args, state)
+		}
+	}
+	// updated_at is always stored in models.TimeLayout, so comparing the text compares the times
+	if !query.UpdatedSince.IsZero() {
+		conditions = append(conditions, "updated_at >= ?")
+		args = append(args, models.NewTime(query.UpdatedSince).String())
+	}
+
+	stmt := `SELECT data FROM contacts`
+	if len(conditions) > 0 {
+		stmt += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return s.queryContacts(stmt+" ORDER BY id", args...)
+}
+
+// contactStateExpr is models.Contact.State written in SQL
+const contactStateExpr = `CASE
+	WHEN COALESCE(json_extract(data, '$.deleted'), 0) THEN 'deleted'
+	WHEN COALESCE(json_extract(data, '$.blocked'), 0) THEN 'blocked'
+	WHEN COALESCE(json_extract(data, '$.active'), 0) THEN 'verified'
+	ELSE 'unverified'
+END`
+
+func (s *SQLite) GetContact(id int) (models.Contact, error) {
+	contacts, err := s.queryContacts(`SELECT data FROM contacts WHERE id = ?`, id)
+	if err != nil {
+		return models.Contact{}, err
+	}
+	if len(contacts) == 0 {
+		return models.Contact{}, ErrRecordNotFound
+	}
+	return contacts[0], nil
+}
+
+func (s *SQLite) ListContacts() ([]models.Contact, error) {
+	return s.queryContacts(`SELECT data FROM contacts ORDER BY id`)
+}
+
+func (s *SQLite) PutContact(contact models.Contact) error {
+	data, err := json.Marshal(contact)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO contacts (id, email, mobile, phone, unique_external_id, updated_at, data) VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET
+			email = excluded.email,
+			mobile = excluded.mobile,
+			phone = excluded.phone,
+			unique_external_id = excluded.unique_external_id,
+			updated_at = excluded.updated_at,
+			data = excluded.data`,
+		contact.ID, contact.Email, contact.Mobile, contact.Phone, contact.PeopleID, contact.UpdatedAt.String(), string(data),
+	)
+	return err
+}
+
+func (s *SQLite) queryContacts(stmt string, args ...interface{}) ([]models.Contact, error) {
+	rows, err := s.db.Query(stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	contacts := []models.Contact{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var contact models.Contact
+		if err := json.Unmarshal([]byte(data), &contact); err != nil {
+			return nil, err
+		}
+		contacts = append(contacts, contact)
+	}
+	return contacts, rows.Err()
+}
+
+func (s *SQLite) DeleteRecord(kind string, id int) error {
+	_, err := s.db.Exec(`DELETE FROM records WHERE kind = ? AND id = ?`, kind, id)
+	return err
+}
+
+func (s *SQLite) GetRecord(kind string, id int, out interface{}) error {
+	var data string
+	err := s.db.QueryRow(`SELECT data FROM records WHERE kind = ? AND id = ?`, kind, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrRecordNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), out)
+}
+
+func (s *SQLite) ListRecords(kind string) ([][]byte, error) {
+	rows, err := s.db.Query(`SELECT data FROM records WHERE kind = ? ORDER BY id`, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	records := [][]byte{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		records = append(records, []byte(data))
+	}
+	return records, rows.Err()
+}
+
+func (s *SQLite) PutRecord(kind string, id int, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(
+		`INSERT INTO records (kind, id, data) VALUES (?, ?, ?)
+		ON CONFLICT (kind, id) DO UPDATE SET data = excluded.data`,
+		kind, id, string(data),
+	)
+	return err
+}
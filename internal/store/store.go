@@ -0,0 +1,200 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+var (
+	ErrRecordNotFound = fmt.Errorf("record not found")
+	ErrCannotOpen     = fmt.Errorf("unable to open data store")
+	ErrMigrationFail  = fmt.Errorf("unable to migrate data store schema")
+)
+
+// Store is the persistence layer used by the controllers
+type Store interface {
+	ContactStore
+	RecordStore
+	Close() error
+}
+
+// ContactStore holds the contact records
+type ContactStore interface {
+	CountContacts() (int, error)
+	DeleteContact(id int) error
+	FindContacts(query ContactQuery) ([]models.Contact, error)
+	GetContact(id int) (models.Contact, error)
+	ListContacts() ([]models.Contact, error)
+	PutContact(contact models.Contact) error
+}
+
+// RecordStore holds any other resource as a JSON document, keyed by resource kind and numeric ID
+type RecordStore interface {
+	DeleteRecord(kind string, id int) error
+	GetRecord(kind string, id int, out interface{}) error
+	ListRecords(kind string) ([][]byte, error)
+	PutRecord(kind string, id int, record interface{}) error
+}
+
+// ContactQuery is the set of conditions used by the contact list and search endpoints.
+// Empty fields are ignored, PeopleIDs and States match any of the listed values.
+type ContactQuery struct {
+	CompanyID int
+	Email     string
+	Mobile    string
+	Phone     string
+	PeopleIDs []string
+	States    []string
+	// UpdatedSince is the earliest updated_at to match
+	UpdatedSince time.Time
+}
+
+// Matches reports whether the contact satisfies every condition in the query
+func (q ContactQuery) Matches(c models.Contact) bool {
+	if q.CompanyID != 0 && c.CompanyID != q.CompanyID {
+		return false
+	}
+	if q.Email != "" && c.Email != q.Email {
+		return false
+	}
+	if q.Mobile != "" && c.Mobile != q.Mobile {
+		return false
+	}
+	if q.Phone != "" && c.Phone != q.Phone {
+		return false
+	}
+	if !q.UpdatedSince.IsZero() && c.UpdatedAt.Before(q.UpdatedSince) {
+		return false
+	}
+	if len(q.States) > 0 && !containsString(q.States, c.State()) {
+		return false
+	}
+	if len(q.PeopleIDs) > 0 {
+		found := false
+		for _, peopleID := range q.PeopleIDs {
+			if c.PeopleID == peopleID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Seed adds the given contacts to the store, but only if it doesn't already hold any
+func Seed(s ContactStore, contacts map[int]models.Contact) (bool, error) {
+	count, err := s.CountContacts()
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	for _, contact := range contacts {
+		if err := s.PutContact(contact); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// ApplySeed writes the contacts of a reloaded seed to the store. Only contacts that are new to the seed, or whose
+// stored record still matches the previous seed, are written, so contacts changed or deleted through the API since
+// they were seeded are left alone. It returns the number of contacts written.
+func ApplySeed(s ContactStore, previous, contacts map[int]models.Contact) (int, error) {
+	applied := 0
+	for id, contact := range contacts {
+		stored, err := s.GetContact(id)
+		switch {
+		case errors.Is(err, ErrRecordNotFound):
+			if _, seeded := previous[id]; seeded {
+				continue
+			}
+		case err != nil:
+			return applied, err
+		default:
+			seeded, ok := previous[id]
+			if !ok || !sameJSON(stored, seeded) {
+				continue
+			}
+		}
+		if err := s.PutContact(contact); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// SeedRecords adds the given records of a kind to the store, but only if it doesn't already hold any of that kind
+func SeedRecords[T any](s RecordStore, kind string, records []T, id func(T) int) (bool, error) {
+	existing, err := s.ListRecords(kind)
+	if err != nil {
+		return false, err
+	}
+	if len(existing) > 0 {
+		return false, nil
+	}
+	for _, record := range records {
+		if err := s.PutRecord(kind, id(record), record); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// ApplyRecordSeed writes the records of a kind from a reloaded seed, following the same rules as ApplySeed does
+// for contacts. It returns the number of records written.
+func ApplyRecordSeed[T any](s RecordStore, kind string, previous, records []T, id func(T) int) (int, error) {
+	seeded := make(map[int]T, len(previous))
+	for _, record := range previous {
+		seeded[id(record)] = record
+	}
+	applied := 0
+	for _, record := range records {
+		var stored T
+		err := s.GetRecord(kind, id(record), &stored)
+		last, wasSeeded := seeded[id(record)]
+		switch {
+		case errors.Is(err, ErrRecordNotFound):
+			if wasSeeded {
+				continue
+			}
+		case err != nil:
+			return applied, err
+		default:
+			if !wasSeeded || !sameJSON(stored, last) {
+				continue
+			}
+		}
+		if err := s.PutRecord(kind, id(record), record); err != nil {
+			return applied, err
+		}
+		applied++
+	}
+	return applied, nil
+}
+
+// sameJSON compares records by their JSON form, as stored times may not share the seed's location
+func sameJSON(a, b interface{}) bool {
+	aJSON, aErr := json.Marshal(a)
+	bJSON, bErr := json.Marshal(b)
+	return aErr == nil && bErr == nil && bytes.Equal(aJSON, bJSON)
+}
@@ -0,0 +1,87 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/SkyMack/staledesk/internal/models"
+)
+
+func seedContact(id int, name string) models.Contact {
+	return models.Contact{ID: id, Email: name + "@example.com", Name: name}
+}
+
+func TestApplySeed(t *testing.T) {
+	previous := map[int]models.Contact{
+		1: seedContact(1, "unchanged"),
+		2: seedContact(2, "modified"),
+		3: seedContact(3, "deleted"),
+		4: seedContact(4, "edited"),
+	}
+	s := NewMemory()
+	if seeded, err := Seed(s, previous); err != nil || !seeded {
+		t.Fatalf("Seed() = %v, %v, want true", seeded, err)
+	}
+
+	// Changes made through the API since the previous seed was applied
+	modified := seedContact(2, "modified")
+	modified.JobTitle = "changed through the API"
+	if err := s.PutContact(modified); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteContact(3); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := seedContact(4, "edited")
+	edited.JobTitle = "changed in the seed"
+	reloaded := map[int]models.Contact{
+		1: seedContact(1, "unchanged"),
+		2: seedContact(2, "modified in the seed"),
+		3: seedContact(3, "deleted"),
+		4: edited,
+		5: seedContact(5, "new"),
+	}
+	applied, err := ApplySeed(s, previous, reloaded)
+	if err != nil {
+		t.Fatalf("ApplySeed() error = %v", err)
+	}
+	// The unchanged contact is rewritten as is, which is harmless
+	if applied != 3 {
+		t.Errorf("ApplySeed() = %d, want 3", applied)
+	}
+
+	want := map[int]models.Contact{
+		1: seedContact(1, "unchanged"),
+		2: modified,
+		4: edited,
+		5: seedContact(5, "new"),
+	}
+	for id, wantContact := range want {
+		got, err := s.GetContact(id)
+		if err != nil {
+			t.Errorf("GetContact(%d) error = %v", id, err)
+			continue
+		}
+		if !sameJSON(got, wantContact) {
+			t.Errorf("GetContact(%d) = %+v, want %+v", id, got, wantContact)
+		}
+	}
+	if _, err := s.GetContact(3); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("GetContact(3) of a contact deleted through the API error = %v, want ErrRecordNotFound", err)
+	}
+}
+
+func TestSeedOnlyFillsAnEmptyStore(t *testing.T) {
+	s := NewMemory()
+	if err := s.PutContact(seedContact(1, "existing")); err != nil {
+		t.Fatal(err)
+	}
+	seeded, err := Seed(s, map[int]models.Contact{2: seedContact(2, "seed")})
+	if err != nil || seeded {
+		t.Fatalf("Seed() = %v, %v, want false", seeded, err)
+	}
+	if _, err := s.GetContact(2); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("GetContact(2) error = %v, want ErrRecordNotFound", err)
+	}
+}